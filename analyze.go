@@ -0,0 +1,295 @@
+package hbase
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+)
+
+// AnalyzeOptions configures a KeyAnalyzer.
+type AnalyzeOptions struct {
+	// PrefixLen is the length in bytes of the key prefixes counted,
+	// defaults to 1
+	PrefixLen int
+	// TopPrefixes is the number of most frequent prefixes reported,
+	// defaults to 20
+	TopPrefixes int
+	// SampleSize is the number of keys kept to suggest split points,
+	// defaults to 10000
+	SampleSize int
+	// Splits is the number of regions split points are suggested for,
+	// defaults to twice the current number of regions
+	Splits int
+}
+
+// KeyAnalyzer collects row keys sampled from a table or from recorded
+// traffic and reports their distribution.
+type KeyAnalyzer struct {
+	opts AnalyzeOptions
+
+	keys     int64
+	prefixes map[string]int64
+	// sample is a reservoir sample of the keys, used to estimate region
+	// loads and split points
+	sample [][]byte
+	rand   *rand.Rand
+	// traffic counts the keys added by Add, and ascending those greater
+	// than the key added before
+	traffic     int64
+	ascending   int64
+	lastTraffic []byte
+}
+
+// NewKeyAnalyzer creates a KeyAnalyzer.
+func NewKeyAnalyzer(opts AnalyzeOptions) *KeyAnalyzer {
+	if opts.PrefixLen <= 0 {
+		opts.PrefixLen = 1
+	}
+	if opts.TopPrefixes <= 0 {
+		opts.TopPrefixes = 20
+	}
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = 10000
+	}
+	return &KeyAnalyzer{
+		opts:     opts,
+		prefixes: map[string]int64{},
+		rand:     rand.New(rand.NewSource(1)),
+	}
+}
+
+// add records a key, reservoir sampling it for split points.
+func (a *KeyAnalyzer) add(key []byte) {
+	a.keys++
+	prefix := key
+	if len(prefix) > a.opts.PrefixLen {
+		prefix = prefix[:a.opts.PrefixLen]
+	}
+	a.prefixes[string(prefix)]++
+	if len(a.sample) < a.opts.SampleSize {
+		a.sample = append(a.sample, key)
+	} else if i := a.rand.Int63n(a.keys); i < int64(a.opts.SampleSize) {
+		a.sample[i] = key
+	}
+}
+
+// Add records a key accessed by traffic. Keys must be added in the order
+// they were accessed to detect monotonically increasing keys.
+func (a *KeyAnalyzer) Add(key []byte) {
+	a.add(key)
+	if a.traffic > 0 && bytes.Compare(key, a.lastTraffic) > 0 {
+		a.ascending++
+	}
+	a.traffic++
+	a.lastTraffic = key
+}
+
+// AddRecords adds the row keys of recorded calls to table, see Recorder.
+// Calls to other tables are ignored, as are all calls if table is empty.
+func (a *KeyAnalyzer) AddRecords(records []*CallRecord, table Text) {
+	for _, r := range records {
+		if !bytes.Equal(TableOf(r.Args), table) {
+			continue
+		}
+		for _, row := range rowsOf(r.Args) {
+			a.Add(row)
+		}
+	}
+}
+
+// SampleTable adds every nth row key of table, scanned with key only
+// filters. Table keys are sorted and are not checked for monotonicity.
+func (a *KeyAnalyzer) SampleTable(h Hbase, table Text, every int) error {
+	if every <= 0 {
+		every = 1
+	}
+	scan := (&ExportOptions{FilterString: Text(countFilter), BatchSize: 1000}).scan()
+	n := 0
+	return scanRows(h, table, scan, 1000, func(r *TRowResult_) error {
+		if n%every == 0 {
+			a.add(r.Row)
+		}
+		n++
+		return nil
+	})
+}
+
+// PrefixCount is the number of keys sharing a prefix.
+type PrefixCount struct {
+	Prefix string  `json:"prefix"`
+	Keys   int64   `json:"keys"`
+	Share  float64 `json:"share"`
+}
+
+// RegionLoad is the number of analyzed keys falling in a region.
+type RegionLoad struct {
+	StartKey string `json:"startKey"`
+	EndKey   string `json:"endKey"`
+	Server   string `json:"server,omitempty"`
+	Keys     int64  `json:"keys"`
+}
+
+// KeyReport is the report of a KeyAnalyzer. Keys are rendered with
+// non-printable bytes escaped as \xNN.
+type KeyReport struct {
+	Keys        int64         `json:"keys"`
+	TrafficKeys int64         `json:"trafficKeys"`
+	Prefixes    []PrefixCount `json:"prefixes"`
+	Regions     []RegionLoad  `json:"regions"`
+	// Skew is the load of the busiest region over the mean region load
+	Skew float64 `json:"skew"`
+	// Ascending is the share of traffic keys greater than the key before
+	Ascending   float64  `json:"ascending"`
+	Warnings    []string `json:"warnings"`
+	SplitPoints []string `json:"splitPoints"`
+	// SaltBuckets suggests a number of salt buckets to prefix keys with
+	// when keys are monotonic or skewed, zero otherwise
+	SaltBuckets int `json:"saltBuckets"`
+}
+
+// renderKey renders a key of a report.
+func renderKey(key []byte) string {
+	return ToStringBinary(key)
+}
+
+// Report analyzes the collected keys against the regions of the table, as
+// returned by GetTableRegions.
+func (a *KeyAnalyzer) Report(regions []*TRegionInfo) *KeyReport {
+	report := &KeyReport{Keys: a.keys, TrafficKeys: a.traffic}
+
+	for prefix, n := range a.prefixes {
+		report.Prefixes = append(report.Prefixes, PrefixCount{
+			Prefix: renderKey([]byte(prefix)),
+			Keys:   n,
+			Share:  float64(n) / float64(a.keys),
+		})
+	}
+	sort.Slice(report.Prefixes, func(i, j int) bool {
+		if report.Prefixes[i].Keys != report.Prefixes[j].Keys {
+			return report.Prefixes[i].Keys > report.Prefixes[j].Keys
+		}
+		return report.Prefixes[i].Prefix < report.Prefixes[j].Prefix
+	})
+	if len(report.Prefixes) > a.opts.TopPrefixes {
+		report.Prefixes = report.Prefixes[:a.opts.TopPrefixes]
+	}
+
+	regions = append([]*TRegionInfo(nil), regions...)
+	sort.Slice(regions, func(i, j int) bool { return bytes.Compare(regions[i].StartKey, regions[j].StartKey) < 0 })
+	// region loads are estimated from the sample
+	loads := make([]int64, len(regions))
+	for _, key := range a.sample {
+		// the last region starting at or before key
+		i := sort.Search(len(regions), func(i int) bool { return bytes.Compare(regions[i].StartKey, key) > 0 }) - 1
+		if i >= 0 {
+			loads[i]++
+		}
+	}
+	for i := range loads {
+		if len(a.sample) > 0 {
+			loads[i] = loads[i] * a.keys / int64(len(a.sample))
+		}
+	}
+	var max, total int64
+	servers := map[string]bool{}
+	for i, r := range regions {
+		report.Regions = append(report.Regions, RegionLoad{
+			StartKey: renderKey(r.StartKey),
+			EndKey:   renderKey(r.EndKey),
+			Server:   string(r.ServerName),
+			Keys:     loads[i],
+		})
+		total += loads[i]
+		if loads[i] > max {
+			max = loads[i]
+		}
+		if len(r.ServerName) > 0 {
+			servers[string(r.ServerName)] = true
+		}
+	}
+	if total > 0 {
+		report.Skew = float64(max) / (float64(total) / float64(len(regions)))
+	}
+	if a.traffic > 1 {
+		report.Ascending = float64(a.ascending) / float64(a.traffic-1)
+	}
+
+	if report.Ascending >= 0.9 && a.traffic >= 10 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"%.0f%% of traffic keys increase monotonically, writes concentrate on the last region", 100*report.Ascending))
+	}
+	if len(regions) > 1 && report.Skew >= 1.5 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"busiest region holds %.1fx the mean region load", report.Skew))
+	}
+	if len(report.Prefixes) > 0 && len(a.prefixes) > 1 && report.Prefixes[0].Share >= 0.5 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"prefix %s holds %.0f%% of the keys", report.Prefixes[0].Prefix, 100*report.Prefixes[0].Share))
+	}
+
+	splits := a.opts.Splits
+	if splits <= 0 {
+		splits = 2 * len(regions)
+		if splits < 2 {
+			splits = 2
+		}
+	}
+	for _, p := range a.splitPoints(splits) {
+		report.SplitPoints = append(report.SplitPoints, renderKey(p))
+	}
+	if len(report.Warnings) > 0 {
+		report.SaltBuckets = len(servers)
+		if report.SaltBuckets < 2 {
+			report.SaltBuckets = splits
+		}
+	}
+	return report
+}
+
+// splitPoints returns the keys splitting the sampled keys into n ranges of
+// equal size.
+func (a *KeyAnalyzer) splitPoints(n int) [][]byte {
+	sample := append([][]byte(nil), a.sample...)
+	sort.Slice(sample, func(i, j int) bool { return bytes.Compare(sample[i], sample[j]) < 0 })
+	var points [][]byte
+	for i := 1; i < n && len(sample) > 0; i++ {
+		p := sample[i*len(sample)/n]
+		if len(points) == 0 || bytes.Compare(p, points[len(points)-1]) > 0 {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// WriteText writes the report in a human readable form.
+func (r *KeyReport) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "keys: %d (%d from traffic)\n", r.Keys, r.TrafficKeys)
+	fmt.Fprintln(&buf, "prefixes:")
+	for _, p := range r.Prefixes {
+		fmt.Fprintf(&buf, "  %-16s %10d %6.2f%%\n", p.Prefix, p.Keys, 100*p.Share)
+	}
+	fmt.Fprintf(&buf, "regions (skew %.2f):\n", r.Skew)
+	for _, region := range r.Regions {
+		fmt.Fprintf(&buf, "  [%s, %s) %s %d\n", region.StartKey, region.EndKey, region.Server, region.Keys)
+	}
+	if r.TrafficKeys > 1 {
+		fmt.Fprintf(&buf, "ascending traffic keys: %.2f%%\n", 100*r.Ascending)
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(&buf, "warning: %s\n", warning)
+	}
+	if len(r.SplitPoints) > 0 {
+		fmt.Fprintln(&buf, "suggested split points:")
+		for _, p := range r.SplitPoints {
+			fmt.Fprintf(&buf, "  %s\n", p)
+		}
+	}
+	if r.SaltBuckets > 0 {
+		fmt.Fprintf(&buf, "suggested salt buckets: %d\n", r.SaltBuckets)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,64 @@
+package hbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestKeyAnalyzer(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("table", "m")
+	for i := 0; i < 100; i++ {
+		m.put("table", fmt.Sprintf("a%03d", i), "cf:q", "", 0)
+	}
+	m.put("table", "z", "cf:q", "", 0)
+	regions, _ := m.GetTableRegions(Text("table"))
+
+	a := NewKeyAnalyzer(AnalyzeOptions{Splits: 4})
+	if err := a.SampleTable(m, Text("table"), 2); err != nil {
+		t.Fatal(err)
+	}
+	report := a.Report(regions)
+	if report.Keys != 51 || report.TrafficKeys != 0 || report.Prefixes[0].Prefix != "a" || report.Prefixes[0].Keys != 50 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if len(report.Regions) != 2 || report.Regions[0].Keys != 50 || report.Skew < 1.9 {
+		t.Fatalf("unexpected region loads %+v", report.Regions)
+	}
+	if len(report.SplitPoints) != 3 || report.SaltBuckets != 4 || len(report.Warnings) != 2 {
+		t.Fatalf("unexpected suggestions %+v", report)
+	}
+
+	// monotonic traffic
+	a = NewKeyAnalyzer(AnalyzeOptions{})
+	var records []*CallRecord
+	for i := 0; i < 20; i++ {
+		records = append(records, &CallRecord{
+			Method: "mutateRows",
+			Args: &MutateRowsArgs{TableName: Text("table"), RowBatches: []*BatchMutation{
+				{Row: Text(fmt.Sprintf("\x00%04d", 2*i))},
+				{Row: Text(fmt.Sprintf("\x00%04d", 2*i+1))},
+			}},
+		})
+	}
+	records = append(records, &CallRecord{Method: "get", Args: &GetArgs{TableName: Text("other"), Row: Text("x")}})
+	a.AddRecords(records, Text("table"))
+	report = a.Report(regions)
+	if report.TrafficKeys != 40 || report.Ascending != 1 || !strings.Contains(report.Warnings[0], "monotonically") {
+		t.Fatalf("unexpected traffic report %+v", report)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `\x000`) || !strings.Contains(buf.String(), "suggested salt buckets: 4") {
+		t.Fatalf("unexpected text report:\n%s", buf.String())
+	}
+	if _, err := json.Marshal(report); err != nil {
+		t.Fatal(err)
+	}
+}
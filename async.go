@@ -0,0 +1,359 @@
+package hbase
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+)
+
+// ErrAsyncClosed is the error of calls submitted to a closed AsyncClient.
+var ErrAsyncClosed = errors.New("async client closed")
+
+// Future is the pending result of an AsyncClient call.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+func (f *Future[T]) complete(value T, err error) {
+	f.value, f.err = value, err
+	close(f.done)
+}
+
+// Done returns a channel closed when the call completes.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get waits for the call to complete and returns its result.
+func (f *Future[T]) Get() (T, error) {
+	<-f.done
+	return f.value, f.err
+}
+
+// Wait is like Get but gives up when ctx is done. The call keeps running
+// and its result can still be read with Get.
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AsyncClient runs calls in the background and returns futures of their
+// results. Calls are spread over a fixed number of lanes, each running its
+// calls one at a time in submission order. All calls on the same row of
+// the same table go to the same lane, so they complete in the order they
+// were submitted while calls on other rows run concurrently. Calls on
+// several rows, such as MutateRows, are split by lane and keep that order
+// for each of their rows.
+//
+// The underlying Hbase must be safe for concurrent use. A Mux with at
+// least as many connections as lanes lets every lane keep a request in
+// flight. The generated thrift client accepts only the reply to its latest
+// request, and the gateway serves the requests of a connection one at a
+// time, so requests are not pipelined on a single connection.
+type AsyncClient struct {
+	h     Hbase
+	lanes []chan func()
+	wg    sync.WaitGroup
+
+	// mu guards closed against submissions racing Close, sending counts
+	// the submissions queuing a call so that Close closes the lanes after
+	// them
+	mu      sync.RWMutex
+	closed  bool
+	sending sync.WaitGroup
+}
+
+// NewAsyncClient creates an AsyncClient running calls on h over lanes
+// lanes, each queuing up to queue calls before submissions block.
+func NewAsyncClient(h Hbase, lanes, queue int) *AsyncClient {
+	if lanes <= 0 {
+		lanes = 1
+	}
+	c := &AsyncClient{h: h, lanes: make([]chan func(), lanes)}
+	for i := range c.lanes {
+		c.lanes[i] = make(chan func(), queue)
+		c.wg.Add(1)
+		go func(calls chan func()) {
+			defer c.wg.Done()
+			for call := range calls {
+				call()
+			}
+		}(c.lanes[i])
+	}
+	return c
+}
+
+// Close waits for submitted calls to complete and stops the lanes. Calls
+// submitted afterwards fail with ErrAsyncClosed.
+func (c *AsyncClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	c.sending.Wait()
+	for _, calls := range c.lanes {
+		close(calls)
+	}
+	c.wg.Wait()
+	return nil
+}
+
+// lane returns the lane of row of table.
+func (c *AsyncClient) lane(table, row Text) int {
+	h := fnv.New32a()
+	h.Write(table)
+	h.Write([]byte{0})
+	h.Write(row)
+	return int(h.Sum32() % uint32(len(c.lanes)))
+}
+
+// submit queues a call on lane, which runs it with an Hbase passing ctx to
+// interceptors, and returns the future of its result.
+func submit[T any](c *AsyncClient, ctx context.Context, lane int, call func(Hbase) (T, error)) *Future[T] {
+	f := newFuture[T]()
+	run := func() {
+		h, err := contextHbase(ctx, c.h)
+		if err != nil {
+			var zero T
+			f.complete(zero, err)
+			return
+		}
+		f.complete(call(h))
+	}
+
+	c.mu.RLock()
+	if c.closed {
+		c.mu.RUnlock()
+		var zero T
+		f.complete(zero, ErrAsyncClosed)
+		return f
+	}
+	c.sending.Add(1)
+	c.mu.RUnlock()
+	// the lanes keep running calls until Close saw this send complete, so
+	// a full queue blocks only this submission, not Close or other lanes
+	c.lanes[lane] <- run
+	c.sending.Done()
+	return f
+}
+
+// splitRows groups the indexes of rows by lane, lanes in order of their
+// first row.
+func (c *AsyncClient) splitRows(table Text, rows [][]byte) [][]int {
+	var groups [][]int
+	index := map[int]int{}
+	for i, row := range rows {
+		lane := c.lane(table, row)
+		g, ok := index[lane]
+		if !ok {
+			g = len(groups)
+			index[lane] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// join returns a future completed with merge of the results of parts, or
+// the first error among them.
+func join[T, R any](parts []*Future[T], merge func([]T) R) *Future[R] {
+	f := newFuture[R]()
+	go func() {
+		values := make([]T, len(parts))
+		for i, p := range parts {
+			v, err := p.Get()
+			if err != nil {
+				var zero R
+				f.complete(zero, err)
+				return
+			}
+			values[i] = v
+		}
+		f.complete(merge(values), nil)
+	}()
+	return f
+}
+
+// Get asynchronously calls Get.
+func (c *AsyncClient) Get(ctx context.Context, tableName, row, column Text, attributes map[string]Text) *Future[[]*TCell] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) ([]*TCell, error) {
+		return h.Get(tableName, row, column, attributes)
+	})
+}
+
+// GetVer asynchronously calls GetVer.
+func (c *AsyncClient) GetVer(ctx context.Context, tableName, row, column Text, numVersions int32, attributes map[string]Text) *Future[[]*TCell] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) ([]*TCell, error) {
+		return h.GetVer(tableName, row, column, numVersions, attributes)
+	})
+}
+
+// GetVerTs asynchronously calls GetVerTs.
+func (c *AsyncClient) GetVerTs(ctx context.Context, tableName, row, column Text, timestamp int64, numVersions int32, attributes map[string]Text) *Future[[]*TCell] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) ([]*TCell, error) {
+		return h.GetVerTs(tableName, row, column, timestamp, numVersions, attributes)
+	})
+}
+
+// GetRow asynchronously calls GetRow.
+func (c *AsyncClient) GetRow(ctx context.Context, tableName, row Text, attributes map[string]Text) *Future[[]*TRowResult_] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) ([]*TRowResult_, error) {
+		return h.GetRow(tableName, row, attributes)
+	})
+}
+
+// GetRowTs asynchronously calls GetRowTs.
+func (c *AsyncClient) GetRowTs(ctx context.Context, tableName, row Text, timestamp int64, attributes map[string]Text) *Future[[]*TRowResult_] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) ([]*TRowResult_, error) {
+		return h.GetRowTs(tableName, row, timestamp, attributes)
+	})
+}
+
+// GetRowWithColumns asynchronously calls GetRowWithColumns.
+func (c *AsyncClient) GetRowWithColumns(ctx context.Context, tableName, row Text, columns [][]byte, attributes map[string]Text) *Future[[]*TRowResult_] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) ([]*TRowResult_, error) {
+		return h.GetRowWithColumns(tableName, row, columns, attributes)
+	})
+}
+
+// GetRowWithColumnsTs asynchronously calls GetRowWithColumnsTs.
+func (c *AsyncClient) GetRowWithColumnsTs(ctx context.Context, tableName, row Text, columns [][]byte, timestamp int64, attributes map[string]Text) *Future[[]*TRowResult_] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) ([]*TRowResult_, error) {
+		return h.GetRowWithColumnsTs(tableName, row, columns, timestamp, attributes)
+	})
+}
+
+// GetRows asynchronously calls GetRows, one call per lane of rows. The
+// results are in the order of rows, which have no result if they do not
+// exist.
+func (c *AsyncClient) GetRows(ctx context.Context, tableName Text, rows [][]byte, attributes map[string]Text) *Future[[]*TRowResult_] {
+	return c.getRows(ctx, tableName, rows, func(h Hbase, keys [][]byte) ([]*TRowResult_, error) {
+		return h.GetRows(tableName, keys, attributes)
+	})
+}
+
+// GetRowsTs asynchronously calls GetRowsTs, one call per lane of rows. The
+// results are in the order of rows, which have no result if they do not
+// exist.
+func (c *AsyncClient) GetRowsTs(ctx context.Context, tableName Text, rows [][]byte, timestamp int64, attributes map[string]Text) *Future[[]*TRowResult_] {
+	return c.getRows(ctx, tableName, rows, func(h Hbase, keys [][]byte) ([]*TRowResult_, error) {
+		return h.GetRowsTs(tableName, keys, timestamp, attributes)
+	})
+}
+
+// GetRowsWithColumns asynchronously calls GetRowsWithColumns, one call per
+// lane of rows. The results are in the order of rows, which have no result
+// if they do not exist.
+func (c *AsyncClient) GetRowsWithColumns(ctx context.Context, tableName Text, rows [][]byte, columns [][]byte, attributes map[string]Text) *Future[[]*TRowResult_] {
+	return c.getRows(ctx, tableName, rows, func(h Hbase, keys [][]byte) ([]*TRowResult_, error) {
+		return h.GetRowsWithColumns(tableName, keys, columns, attributes)
+	})
+}
+
+// GetRowsWithColumnsTs asynchronously calls GetRowsWithColumnsTs, one call
+// per lane of rows. The results are in the order of rows, which have no
+// result if they do not exist.
+func (c *AsyncClient) GetRowsWithColumnsTs(ctx context.Context, tableName Text, rows [][]byte, columns [][]byte, timestamp int64, attributes map[string]Text) *Future[[]*TRowResult_] {
+	return c.getRows(ctx, tableName, rows, func(h Hbase, keys [][]byte) ([]*TRowResult_, error) {
+		return h.GetRowsWithColumnsTs(tableName, keys, columns, timestamp, attributes)
+	})
+}
+
+func (c *AsyncClient) getRows(ctx context.Context, tableName Text, rows [][]byte, get func(Hbase, [][]byte) ([]*TRowResult_, error)) *Future[[]*TRowResult_] {
+	var parts []*Future[[]*TRowResult_]
+	for _, group := range c.splitRows(tableName, rows) {
+		keys := make([][]byte, len(group))
+		for i, index := range group {
+			keys[i] = rows[index]
+		}
+		parts = append(parts, submit(c, ctx, c.lane(tableName, keys[0]), func(h Hbase) ([]*TRowResult_, error) {
+			return get(h, keys)
+		}))
+	}
+	return join(parts, func(values [][]*TRowResult_) []*TRowResult_ {
+		byRow := map[string]*TRowResult_{}
+		for _, results := range values {
+			for _, r := range results {
+				byRow[string(r.Row)] = r
+			}
+		}
+		var results []*TRowResult_
+		for _, row := range rows {
+			if r, ok := byRow[string(row)]; ok {
+				results = append(results, r)
+			}
+		}
+		return results
+	})
+}
+
+// MutateRow asynchronously calls MutateRow.
+func (c *AsyncClient) MutateRow(ctx context.Context, tableName, row Text, mutations []*Mutation, attributes map[string]Text) *Future[struct{}] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) (struct{}, error) {
+		return struct{}{}, h.MutateRow(tableName, row, mutations, attributes)
+	})
+}
+
+// MutateRowTs asynchronously calls MutateRowTs.
+func (c *AsyncClient) MutateRowTs(ctx context.Context, tableName, row Text, mutations []*Mutation, timestamp int64, attributes map[string]Text) *Future[struct{}] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) (struct{}, error) {
+		return struct{}{}, h.MutateRowTs(tableName, row, mutations, timestamp, attributes)
+	})
+}
+
+// MutateRows asynchronously calls MutateRows, one call per lane of the
+// rows of rowBatches.
+func (c *AsyncClient) MutateRows(ctx context.Context, tableName Text, rowBatches []*BatchMutation, attributes map[string]Text) *Future[struct{}] {
+	return c.mutateRows(ctx, tableName, rowBatches, func(h Hbase, batches []*BatchMutation) error {
+		return h.MutateRows(tableName, batches, attributes)
+	})
+}
+
+// MutateRowsTs asynchronously calls MutateRowsTs, one call per lane of the
+// rows of rowBatches.
+func (c *AsyncClient) MutateRowsTs(ctx context.Context, tableName Text, rowBatches []*BatchMutation, timestamp int64, attributes map[string]Text) *Future[struct{}] {
+	return c.mutateRows(ctx, tableName, rowBatches, func(h Hbase, batches []*BatchMutation) error {
+		return h.MutateRowsTs(tableName, batches, timestamp, attributes)
+	})
+}
+
+func (c *AsyncClient) mutateRows(ctx context.Context, tableName Text, rowBatches []*BatchMutation, mutate func(Hbase, []*BatchMutation) error) *Future[struct{}] {
+	rows := make([][]byte, len(rowBatches))
+	for i, b := range rowBatches {
+		rows[i] = b.Row
+	}
+	var parts []*Future[struct{}]
+	for _, group := range c.splitRows(tableName, rows) {
+		batches := make([]*BatchMutation, len(group))
+		for i, index := range group {
+			batches[i] = rowBatches[index]
+		}
+		parts = append(parts, submit(c, ctx, c.lane(tableName, batches[0].Row), func(h Hbase) (struct{}, error) {
+			return struct{}{}, mutate(h, batches)
+		}))
+	}
+	return join(parts, func([]struct{}) struct{} { return struct{}{} })
+}
+
+// AtomicIncrement asynchronously calls AtomicIncrement.
+func (c *AsyncClient) AtomicIncrement(ctx context.Context, tableName, row, column Text, value int64) *Future[int64] {
+	return submit(c, ctx, c.lane(tableName, row), func(h Hbase) (int64, error) {
+		return h.AtomicIncrement(tableName, row, column, value)
+	})
+}
@@ -0,0 +1,164 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncClient(t *testing.T) {
+	ctx := context.Background()
+	m := newMemHbase()
+	m.createTable("t")
+
+	// record the order values reach each row, with jitter so that calls on
+	// different lanes interleave
+	var mu sync.Mutex
+	order := map[string][]string{}
+	h := Intercept(m, func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+		if a, ok := args.(*MutateRowArgs); ok {
+			mu.Lock()
+			order[string(a.Row)] = append(order[string(a.Row)], string(a.Mutations[0].Value))
+			mu.Unlock()
+		}
+		return invoker(ctx, method, args, result)
+	})
+	c := NewAsyncClient(h, 4, 8)
+
+	var futures []*Future[struct{}]
+	want := map[string][]string{}
+	for i := 0; i < 50; i++ {
+		row := fmt.Sprintf("row%d", i%5)
+		value := fmt.Sprint(i)
+		want[row] = append(want[row], value)
+		futures = append(futures, c.MutateRow(ctx, Text("t"), Text(row), []*Mutation{{Column: Text("cf:a"), Value: Text(value)}}, nil))
+	}
+	for _, f := range futures {
+		if _, err := f.Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("unexpected per row order %v", order)
+	}
+
+	var batches []*BatchMutation
+	for i := 0; i < 10; i++ {
+		batches = append(batches, &BatchMutation{Row: Text(fmt.Sprintf("b%d", i)), Mutations: []*Mutation{{Column: Text("cf:a"), Value: Text("v")}}})
+	}
+	if _, err := c.MutateRows(ctx, Text("t"), batches, nil).Get(); err != nil {
+		t.Fatal(err)
+	}
+	keys := [][]byte{[]byte("b7"), []byte("none"), []byte("row3"), []byte("b0"), []byte("b4")}
+	results, err := c.GetRows(ctx, Text("t"), keys, nil).Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for _, r := range results {
+		rows = append(rows, string(r.Row))
+	}
+	if !reflect.DeepEqual(rows, []string{"b7", "row3", "b0", "b4"}) {
+		t.Fatalf("unexpected rows %q", rows)
+	}
+	if cells, err := c.Get(ctx, Text("t"), Text("row3"), Text("cf:a"), nil).Get(); err != nil || string(cells[0].Value) != "48" {
+		t.Fatalf("unexpected cells %v, %v", cells, err)
+	}
+
+	if _, err := c.MutateRows(ctx, Text("missing"), batches, nil).Get(); err == nil {
+		t.Fatalf("expected error of missing table")
+	}
+	c.Close()
+	if _, err := c.GetRow(ctx, Text("t"), Text("row0"), nil).Get(); err != ErrAsyncClosed {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestAsyncClientTs(t *testing.T) {
+	ctx := context.Background()
+	m := newMemHbase()
+	m.createTable("t")
+	c := NewAsyncClient(m, 2, 4)
+	defer c.Close()
+
+	for _, ts := range []int64{10, 20} {
+		mutations := []*Mutation{{Column: Text("cf:a"), Value: Text(fmt.Sprint(ts))}}
+		for _, row := range []string{"r1", "r2"} {
+			if _, err := c.MutateRowTs(ctx, Text("t"), Text(row), mutations, ts, nil).Get(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if rows, err := c.GetRowTs(ctx, Text("t"), Text("r1"), 15, nil).Get(); err != nil || string(rows[0].Columns["cf:a"].Value) != "10" {
+		t.Fatalf("unexpected rows %v, %v", rows, err)
+	}
+	rows, err := c.GetRowsWithColumnsTs(ctx, Text("t"), [][]byte{[]byte("r2"), []byte("r1")}, [][]byte{[]byte("cf")}, 15, nil).Get()
+	if err != nil || len(rows) != 2 || string(rows[0].Row) != "r2" || string(rows[1].Columns["cf:a"].Value) != "10" {
+		t.Fatalf("unexpected rows %v, %v", rows, err)
+	}
+}
+
+func TestAsyncClientCloseWhileQueueFull(t *testing.T) {
+	ctx := context.Background()
+	m := newMemHbase()
+	m.createTable("t")
+	release := make(chan struct{})
+	h := Intercept(m, func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		if a, ok := args.(*MutateRowArgs); ok && string(a.Row) == "block" {
+			<-release
+		}
+		return invoker(ctx, method, args, result)
+	})
+	c := NewAsyncClient(h, 1, 0)
+
+	blocked := c.MutateRow(ctx, Text("t"), Text("block"), []*Mutation{{Column: Text("cf:a"), Value: Text("v")}}, nil)
+	queued := make(chan *Future[[]*TRowResult_])
+	go func() {
+		// blocks until the lane takes it
+		queued <- c.GetRow(ctx, Text("t"), Text("block"), nil)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+
+	// submissions fail fast while Close waits for the queued one
+	rejected := make(chan error)
+	go func() {
+		for {
+			c.mu.RLock()
+			closing := c.closed
+			c.mu.RUnlock()
+			if closing {
+				_, err := c.GetRow(ctx, Text("t"), Text("x"), nil).Get()
+				rejected <- err
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	select {
+	case err := <-rejected:
+		if err != ErrAsyncClosed {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("submission blocked behind a full queue")
+	}
+
+	close(release)
+	if _, err := blocked.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if rows, err := (<-queued).Get(); err != nil || len(rows) != 1 {
+		t.Fatalf("unexpected rows %v, %v", rows, err)
+	}
+	<-closed
+}
@@ -0,0 +1,313 @@
+package hbase
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BackupManifestFile is the name of the manifest of a backup directory
+const BackupManifestFile = "manifest.json"
+
+// BackupManifest lists the backups of a table kept in a directory. Backups
+// are ordered by time; every incremental backup covers the cells written
+// since the backup before it.
+type BackupManifest struct {
+	Table   string       `json:"table"`
+	Backups []BackupInfo `json:"backups"`
+}
+
+// BackupInfo describes a backup.
+type BackupInfo struct {
+	ID string `json:"id"`
+	// Incremental is set for backups of the cells written in (Since, Until]
+	Incremental bool `json:"incremental"`
+	// Since and Until are cell timestamps in milliseconds
+	Since   int64     `json:"since"`
+	Until   int64     `json:"until"`
+	Files   []string  `json:"files"`
+	Rows    int       `json:"rows"`
+	Cells   int       `json:"cells"`
+	Created time.Time `json:"created"`
+}
+
+// ReadBackupManifest reads the manifest of a backup directory. A missing
+// manifest is returned empty.
+func ReadBackupManifest(dir string) (*BackupManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, BackupManifestFile))
+	if os.IsNotExist(err) {
+		return &BackupManifest{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	m := &BackupManifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %v", BackupManifestFile, err)
+	}
+	return m, nil
+}
+
+// write atomically replaces the manifest of dir.
+func (m *BackupManifest) write(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(dir, BackupManifestFile)
+	if err := os.WriteFile(name+".tmp", append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// BackupOptions configures Backup.
+type BackupOptions struct {
+	// Incremental backs up the cells written since the last backup of the
+	// directory. A full backup is taken if there is none.
+	Incremental bool
+	// Until is the timestamp, in milliseconds, of the latest cells backed
+	// up. Defaults to the current time.
+	Until int64
+	// Compress gzips the backup files
+	Compress bool
+	// MaxRows starts a new backup file after this many rows
+	MaxRows int
+	// BatchSize is the number of rows fetched per ScannerGetList
+	BatchSize int32
+}
+
+// Backup takes a full or incremental backup of table into dir and records
+// it in the manifest of dir. Backup files hold TRowResult_ structs as
+// written by ExportBinary.
+//
+// Thrift scans return the latest version of every cell, so a backup holds
+// the latest version of each cell as of Until, and deletes are not
+// recorded.
+func Backup(h Hbase, table Text, dir string, opts BackupOptions) (*BackupInfo, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	manifest, err := ReadBackupManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Table == "" {
+		manifest.Table = string(table)
+	} else if manifest.Table != string(table) {
+		return nil, fmt.Errorf("%s holds backups of table %s", dir, manifest.Table)
+	}
+
+	info := BackupInfo{
+		Until:   opts.Until,
+		Created: time.Now(),
+	}
+	if info.Until == 0 {
+		info.Until = info.Created.UnixNano() / int64(time.Millisecond)
+	}
+	if n := len(manifest.Backups); opts.Incremental && n > 0 {
+		info.Incremental = true
+		info.Since = manifest.Backups[n-1].Until
+		if info.Since >= info.Until {
+			return nil, fmt.Errorf("last backup already covers timestamp %d", info.Until)
+		}
+	}
+	kind := "full"
+	if info.Incremental {
+		kind = "incr"
+	}
+	info.ID = fmt.Sprintf("%05d-%s", len(manifest.Backups), kind)
+
+	w := NewSplitWriter(filepath.Join(dir, info.ID), ExportBinary, EncodeBase64, opts.Compress, opts.MaxRows, 0)
+	// scans cover [0, ts), the backup covers [0, Until]
+	scan := (&ExportOptions{Timestamp: info.Until + 1, BatchSize: opts.BatchSize}).scan()
+	err = scanRows(h, table, scan, opts.BatchSize, func(r *TRowResult_) error {
+		if info.Incremental {
+			if r = cellsSince(r, info.Since); r == nil {
+				return nil
+			}
+		}
+		info.Rows++
+		info.Cells += len(r.Columns) + len(r.SortedColumns)
+		return w.WriteRow(r)
+	})
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range w.Files() {
+		info.Files = append(info.Files, filepath.Base(f))
+	}
+
+	manifest.Backups = append(manifest.Backups, info)
+	if err := manifest.write(dir); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// cellsSince returns the cells of r written after since, or nil if there
+// are none.
+func cellsSince(r *TRowResult_, since int64) *TRowResult_ {
+	kept := &TRowResult_{Row: r.Row, Columns: map[string]*TCell{}}
+	for _, col := range sortedColumns(r) {
+		if col.Cell.Timestamp > since {
+			kept.Columns[string(col.ColumnName)] = col.Cell
+		}
+	}
+	if len(kept.Columns) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// RestoreOptions configures Restore.
+type RestoreOptions struct {
+	// PointInTime is the timestamp, in milliseconds, to restore the table
+	// to. Defaults to the latest backup.
+	PointInTime int64
+	// StartRow and StopRow restrict the restore to a key range
+	StartRow Text
+	StopRow  Text
+	// BatchSize is the number of rows per MutateRowsTs call
+	BatchSize int
+}
+
+// restoreChain returns the backups to apply, in order, to restore to pit:
+// the last full backup taken at or before pit and the incremental backups
+// following it up to the first one covering pit.
+func (m *BackupManifest) restoreChain(pit int64) ([]BackupInfo, error) {
+	full := -1
+	for i, b := range m.Backups {
+		if !b.Incremental && (b.Until <= pit || full < 0) {
+			full = i
+		}
+	}
+	if full < 0 {
+		return nil, fmt.Errorf("no full backup of %s", m.Table)
+	}
+	if m.Backups[full].Until > pit {
+		return nil, fmt.Errorf("no backup of %s before timestamp %d", m.Table, pit)
+	}
+	chain := []BackupInfo{m.Backups[full]}
+	for _, b := range m.Backups[full+1:] {
+		if !b.Incremental || b.Since >= pit {
+			break
+		}
+		chain = append(chain, b)
+	}
+	return chain, nil
+}
+
+// Restore writes the cells of the backups in dir to table, as of the
+// chosen point in time, keeping their original timestamps. The restore is
+// exact at the Until time of a backup; in between, cells overwritten after
+// the point in time but before the next backup are restored to their
+// previous backup.
+//
+// Cells written to table after the point in time are not removed, so
+// restoring into an empty table gives the state of the backed up one.
+func Restore(h Hbase, table Text, dir string, opts RestoreOptions) (ImportStats, error) {
+	var stats ImportStats
+	manifest, err := ReadBackupManifest(dir)
+	if err != nil {
+		return stats, err
+	}
+	pit := opts.PointInTime
+	if pit == 0 && len(manifest.Backups) > 0 {
+		pit = manifest.Backups[len(manifest.Backups)-1].Until
+	}
+	chain, err := manifest.restoreChain(pit)
+	if err != nil {
+		return stats, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	im := &importer{h: h, table: table, timestamps: true}
+	for _, b := range chain {
+		for _, name := range b.Files {
+			if err := restoreFile(im, filepath.Join(dir, name), pit, opts, &stats); err != nil {
+				return stats, fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// restoreFile restores the cells of a backup file.
+func restoreFile(im *importer, name string, pit int64, opts RestoreOptions, stats *ImportStats) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	rows, err := newRowReader(bufio.NewReader(r), ImportOptions{Format: ExportBinary})
+	if err != nil {
+		return err
+	}
+
+	batch := &importBatch{}
+	for {
+		row, err := rows.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		stats.Records++
+		if !inRange(row.row, opts.StartRow, opts.StopRow) {
+			continue
+		}
+		var cells []importCell
+		for _, c := range row.cells {
+			if c.ts <= pit {
+				cells = append(cells, c)
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		row.cells = cells
+		stats.Rows++
+		stats.Cells += len(cells)
+		batch.rows = append(batch.rows, row)
+		if len(batch.rows) >= opts.BatchSize {
+			if err := im.write(batch); err != nil {
+				return err
+			}
+			batch = &importBatch{}
+		}
+	}
+	if len(batch.rows) > 0 {
+		return im.write(batch)
+	}
+	return nil
+}
+
+// inRange reports whether row is in [start, stop), an empty bound being
+// unbounded.
+func inRange(row, start, stop []byte) bool {
+	if len(start) > 0 && bytes.Compare(row, start) < 0 {
+		return false
+	}
+	return len(stop) == 0 || bytes.Compare(row, stop) < 0
+}
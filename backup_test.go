@@ -0,0 +1,73 @@
+package hbase
+
+import (
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	src := newMemHbase()
+	src.createTable("table")
+	src.put("table", "a", "cf:q", "a1", 10)
+	src.put("table", "b", "cf:q", "b1", 10)
+	if _, err := Backup(src, Text("table"), dir, BackupOptions{Until: 100, Compress: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	src.put("table", "a", "cf:q", "a2", 150)
+	src.put("table", "c", "cf:q", "c1", 150)
+	// written after the incremental backup
+	src.put("table", "b", "cf:q", "b2", 300)
+	info, err := Backup(src, Text("table"), dir, BackupOptions{Incremental: true, Until: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Incremental || info.Since != 100 || info.Rows != 2 || info.Cells != 2 {
+		t.Fatalf("unexpected incremental backup %+v", info)
+	}
+	if _, err := Backup(src, Text("table"), dir, BackupOptions{Incremental: true, Until: 200}); err == nil {
+		t.Fatal("expected an error for an empty time window")
+	}
+	if _, err := Backup(src, Text("other"), dir, BackupOptions{}); err == nil {
+		t.Fatal("expected an error for a different table")
+	}
+
+	value := func(h *memHbase, row string) (string, int64) {
+		r, _ := h.GetRow(Text("table"), Text(row), nil)
+		if len(r) == 0 {
+			return "", 0
+		}
+		c := r[0].Columns["cf:q"]
+		return string(c.Value), c.Timestamp
+	}
+
+	dst := newMemHbase()
+	dst.createTable("table")
+	if _, err := Restore(dst, Text("table"), dir, RestoreOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if v, ts := value(dst, "a"); v != "a2" || ts != 150 {
+		t.Fatalf("unexpected restored cell %q at %d", v, ts)
+	}
+	if v, _ := value(dst, "b"); v != "b1" {
+		t.Fatalf("unexpected restored cell %q", v)
+	}
+
+	// point in time before the incremental backup, restricted to [a, c)
+	dst = newMemHbase()
+	dst.createTable("table")
+	stats, err := Restore(dst, Text("table"), dir, RestoreOptions{PointInTime: 120, StopRow: Text("c")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ts := value(dst, "a"); v != "a1" || ts != 10 || stats.Rows != 2 {
+		t.Fatalf("unexpected restored cell %q at %d, stats %+v", v, ts, stats)
+	}
+	if v, _ := value(dst, "c"); v != "" {
+		t.Fatalf("restored row c out of range: %q", v)
+	}
+
+	if _, err := Restore(dst, Text("table"), dir, RestoreOptions{PointInTime: 5}); err == nil {
+		t.Fatal("expected an error before the first backup")
+	}
+}
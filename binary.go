@@ -0,0 +1,113 @@
+package hbase
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+const hexDigits = "0123456789ABCDEF"
+
+// ToStringBinary renders b the way HBase's Bytes.toStringBinary does:
+// printable ASCII is kept as is and every other byte, including the
+// backslash, is written as \xNN so that the result can be parsed back by
+// ToBytesBinary.
+func ToStringBinary(b []byte) string {
+	buf := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c >= ' ' && c <= '~' && c != '\\' {
+			buf = append(buf, c)
+			continue
+		}
+		buf = append(buf, '\\', 'x', hexDigits[c>>4], hexDigits[c&0x0F])
+	}
+	return string(buf)
+}
+
+// ToBytesBinary parses a string produced by ToStringBinary or by HBase's
+// Bytes.toStringBinary. Each \xNN sequence, in either case, becomes a single
+// byte; a backslash that does not start such a sequence is kept literally.
+func ToBytesBinary(s string) []byte {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && s[i+1] == 'x' {
+			hi, okHi := unhex(s[i+2])
+			lo, okLo := unhex(s[i+3])
+			if okHi && okLo {
+				buf = append(buf, hi<<4|lo)
+				i += 3
+				continue
+			}
+		}
+		buf = append(buf, s[i])
+	}
+	return buf
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// stringBinaryList renders a list of keys like fmt does with %v, escaping
+// each one with ToStringBinary.
+func stringBinaryList(list [][]byte) string {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, b := range list {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(ToStringBinary(b))
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+// stringBinaryCells renders a column map in column order, escaping the
+// column names with ToStringBinary.
+func stringBinaryCells(cells map[string]*TCell) string {
+	names := make([]string, 0, len(cells))
+	for name := range cells {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	buf.WriteString("map[")
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(ToStringBinary([]byte(name)))
+		buf.WriteByte(':')
+		buf.WriteString(cells[name].String())
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+// stringOptional renders an optional thrift field, dereferencing it when set.
+func stringOptional(v interface{}) string {
+	switch p := v.(type) {
+	case *int64:
+		if p != nil {
+			return fmt.Sprint(*p)
+		}
+	case *int32:
+		if p != nil {
+			return fmt.Sprint(*p)
+		}
+	case *bool:
+		if p != nil {
+			return fmt.Sprint(*p)
+		}
+	}
+	return "<nil>"
+}
@@ -0,0 +1,56 @@
+package hbase
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestToStringBinary(t *testing.T) {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	if got := ToBytesBinary(ToStringBinary(all)); !bytes.Equal(got, all) {
+		t.Fatalf("round trip mismatch %q", got)
+	}
+
+	for _, c := range []struct {
+		in   []byte
+		want string
+	}{
+		{[]byte("row-1"), "row-1"},
+		{[]byte("\x00row\xff"), `\x00row\xFF`},
+		{[]byte(`a\b`), `a\x5Cb`},
+		{[]byte("tab\tnl\n"), `tab\x09nl\x0A`},
+	} {
+		if got := ToStringBinary(c.in); got != c.want {
+			t.Errorf("ToStringBinary(%q) = %s, want %s", c.in, got, c.want)
+		}
+	}
+
+	// lower-case hex and stray backslashes, as produced by hand or by HBase
+	if got := ToBytesBinary(`\xff\x0a\x\xZZ\`); !bytes.Equal(got, []byte("\xff\n\\x\\xZZ\\")) {
+		t.Fatalf("unexpected parse %q", got)
+	}
+}
+
+func TestStringBinaryStringers(t *testing.T) {
+	ts := int64(10)
+	for _, c := range []struct {
+		in   interface{ String() string }
+		want string
+	}{
+		{&TCell{Value: Bytes("\x01v"), Timestamp: 3}, `TCell({Value:\x01v Timestamp:3})`},
+		{&Mutation{Column: Text("cf:\xfe"), Value: Text("v"), WriteToWAL: true},
+			`Mutation({IsDelete:false Column:cf:\xFE Value:v WriteToWAL:true})`},
+		{&TRowResult_{Row: Text("r\x00"), Columns: map[string]*TCell{
+			"cf:b": {Value: Bytes("2")}, "cf:\x00": {Value: Bytes("1")},
+		}}, `TRowResult_({Row:r\x00 Columns:map[cf:\x00:TCell({Value:1 Timestamp:0}) cf:b:TCell({Value:2 Timestamp:0})] SortedColumns:[]})`},
+		{&TScan{StartRow: Text("\xff"), Timestamp: &ts, Columns: [][]byte{[]byte("cf:\x01")}},
+			`TScan({StartRow:\xFF StopRow: Timestamp:10 Columns:[cf:\x01] Caching:<nil> FilterString: BatchSize:<nil> SortColumns:<nil> Reversed:<nil>})`},
+	} {
+		if got := c.in.String(); got != c.want {
+			t.Errorf("got %s\nwant %s", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package hbase
+
+import (
+	"reflect"
+)
+
+// this file contains helpers for interceptors to read the common fields of
+// the thrift argument structs passed to them
+
+// argsField returns the named field of a thrift args struct, or an invalid
+// reflect.Value if it has no such field.
+func argsField(args interface{}, name string) reflect.Value {
+	v := reflect.ValueOf(args)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return v.Elem().FieldByName(name)
+}
+
+// TableOf returns the table addressed by a call's args, or nil if the call
+// is not bound to a table (e.g. scanner calls after the scanner is opened).
+func TableOf(args interface{}) Text {
+	switch a := args.(type) {
+	case *IncrementArgs:
+		if a.Increment != nil {
+			return a.Increment.Table
+		}
+		return nil
+	case *IncrementRowsArgs:
+		if len(a.Increments) > 0 {
+			return a.Increments[0].Table
+		}
+		return nil
+	case *AppendArgs:
+		if a.Append != nil {
+			return a.Append.Table
+		}
+		return nil
+	}
+	for _, name := range []string{"TableName", "TableNameOrRegionName"} {
+		if f := argsField(args, name); f.IsValid() {
+			return Text(f.Bytes())
+		}
+	}
+	return nil
+}
+
+// RowOf returns the row, start row or prefix addressed by a call's args, or
+// nil if the call has no single row.
+func RowOf(args interface{}) Text {
+	switch a := args.(type) {
+	case *IncrementArgs:
+		if a.Increment != nil {
+			return a.Increment.Row
+		}
+		return nil
+	case *AppendArgs:
+		if a.Append != nil {
+			return a.Append.Row
+		}
+		return nil
+	case *ScannerOpenWithScanArgs:
+		if a.Scan != nil {
+			return a.Scan.StartRow
+		}
+		return nil
+	}
+	for _, name := range []string{"Row", "StartRow", "StartAndPrefix"} {
+		if f := argsField(args, name); f.IsValid() {
+			return Text(f.Bytes())
+		}
+	}
+	return nil
+}
+
+// RowCount returns the number of rows a call's args address.
+func RowCount(args interface{}) int {
+	switch a := args.(type) {
+	case *GetRowsArgs:
+		return len(a.Rows)
+	case *GetRowsTsArgs:
+		return len(a.Rows)
+	case *GetRowsWithColumnsArgs:
+		return len(a.Rows)
+	case *GetRowsWithColumnsTsArgs:
+		return len(a.Rows)
+	case *MutateRowsArgs:
+		return len(a.RowBatches)
+	case *MutateRowsTsArgs:
+		return len(a.RowBatches)
+	case *IncrementRowsArgs:
+		return len(a.Increments)
+	}
+	if RowOf(args) != nil {
+		return 1
+	}
+	return 0
+}
+
+// rowsOf returns the rows a call's args address.
+func rowsOf(args interface{}) [][]byte {
+	switch a := args.(type) {
+	case *GetRowsArgs:
+		return a.Rows
+	case *GetRowsTsArgs:
+		return a.Rows
+	case *GetRowsWithColumnsArgs:
+		return a.Rows
+	case *GetRowsWithColumnsTsArgs:
+		return a.Rows
+	case *MutateRowsArgs:
+		return batchRows(a.RowBatches)
+	case *MutateRowsTsArgs:
+		return batchRows(a.RowBatches)
+	case *IncrementRowsArgs:
+		rows := make([][]byte, len(a.Increments))
+		for i, inc := range a.Increments {
+			rows[i] = inc.Row
+		}
+		return rows
+	}
+	if row := RowOf(args); row != nil {
+		return [][]byte{row}
+	}
+	return nil
+}
+
+func batchRows(batches []*BatchMutation) [][]byte {
+	rows := make([][]byte, len(batches))
+	for i, b := range batches {
+		rows[i] = b.Row
+	}
+	return rows
+}
+
+// AttributesOf returns the attributes of a call's args. ok is false if the
+// method takes no attributes.
+func AttributesOf(args interface{}) (attributes map[string]Text, ok bool) {
+	f := argsField(args, "Attributes")
+	if !f.IsValid() {
+		return nil, false
+	}
+	return f.Interface().(map[string]Text), true
+}
+
+// SetAttributes replaces the attributes of a call's args. It returns false
+// if the method takes no attributes.
+func SetAttributes(args interface{}, attributes map[string]Text) bool {
+	f := argsField(args, "Attributes")
+	if !f.IsValid() {
+		return false
+	}
+	f.Set(reflect.ValueOf(attributes))
+	return true
+}
+
+// ResultRows returns the number of rows in a call's result. ok is false if
+// the method does not return rows.
+func ResultRows(result interface{}) (rows int, ok bool) {
+	success := resultSuccess(result)
+	if !success.IsValid() {
+		return 0, false
+	}
+	r, ok := success.Interface().([]*TRowResult_)
+	return len(r), ok
+}
+
+// calls maps every Hbase method to a constructor of its thrift args and
+// result structs
+var calls = map[string]func() (args, result interface{}){
+	"Append": func() (interface{}, interface{}) {
+		return NewAppendArgs(), NewAppendResult()
+	},
+	"AtomicIncrement": func() (interface{}, interface{}) {
+		return NewAtomicIncrementArgs(), NewAtomicIncrementResult()
+	},
+	"CheckAndPut": func() (interface{}, interface{}) {
+		return NewCheckAndPutArgs(), NewCheckAndPutResult()
+	},
+	"Compact": func() (interface{}, interface{}) {
+		return NewCompactArgs(), NewCompactResult()
+	},
+	"CreateTable": func() (interface{}, interface{}) {
+		return NewCreateTableArgs(), NewCreateTableResult()
+	},
+	"DeleteAll": func() (interface{}, interface{}) {
+		return NewDeleteAllArgs(), NewDeleteAllResult()
+	},
+	"DeleteAllRow": func() (interface{}, interface{}) {
+		return NewDeleteAllRowArgs(), NewDeleteAllRowResult()
+	},
+	"DeleteAllRowTs": func() (interface{}, interface{}) {
+		return NewDeleteAllRowTsArgs(), NewDeleteAllRowTsResult()
+	},
+	"DeleteAllTs": func() (interface{}, interface{}) {
+		return NewDeleteAllTsArgs(), NewDeleteAllTsResult()
+	},
+	"DeleteTable": func() (interface{}, interface{}) {
+		return NewDeleteTableArgs(), NewDeleteTableResult()
+	},
+	"DisableTable": func() (interface{}, interface{}) {
+		return NewDisableTableArgs(), NewDisableTableResult()
+	},
+	"EnableTable": func() (interface{}, interface{}) {
+		return NewEnableTableArgs(), NewEnableTableResult()
+	},
+	"Get": func() (interface{}, interface{}) {
+		return NewGetArgs(), NewGetResult()
+	},
+	"GetColumnDescriptors": func() (interface{}, interface{}) {
+		return NewGetColumnDescriptorsArgs(), NewGetColumnDescriptorsResult()
+	},
+	"GetRegionInfo": func() (interface{}, interface{}) {
+		return NewGetRegionInfoArgs(), NewGetRegionInfoResult()
+	},
+	"GetRow": func() (interface{}, interface{}) {
+		return NewGetRowArgs(), NewGetRowResult()
+	},
+	"GetRowTs": func() (interface{}, interface{}) {
+		return NewGetRowTsArgs(), NewGetRowTsResult()
+	},
+	"GetRowWithColumns": func() (interface{}, interface{}) {
+		return NewGetRowWithColumnsArgs(), NewGetRowWithColumnsResult()
+	},
+	"GetRowWithColumnsTs": func() (interface{}, interface{}) {
+		return NewGetRowWithColumnsTsArgs(), NewGetRowWithColumnsTsResult()
+	},
+	"GetRows": func() (interface{}, interface{}) {
+		return NewGetRowsArgs(), NewGetRowsResult()
+	},
+	"GetRowsTs": func() (interface{}, interface{}) {
+		return NewGetRowsTsArgs(), NewGetRowsTsResult()
+	},
+	"GetRowsWithColumns": func() (interface{}, interface{}) {
+		return NewGetRowsWithColumnsArgs(), NewGetRowsWithColumnsResult()
+	},
+	"GetRowsWithColumnsTs": func() (interface{}, interface{}) {
+		return NewGetRowsWithColumnsTsArgs(), NewGetRowsWithColumnsTsResult()
+	},
+	"GetTableNames": func() (interface{}, interface{}) {
+		return NewGetTableNamesArgs(), NewGetTableNamesResult()
+	},
+	"GetTableRegions": func() (interface{}, interface{}) {
+		return NewGetTableRegionsArgs(), NewGetTableRegionsResult()
+	},
+	"GetVer": func() (interface{}, interface{}) {
+		return NewGetVerArgs(), NewGetVerResult()
+	},
+	"GetVerTs": func() (interface{}, interface{}) {
+		return NewGetVerTsArgs(), NewGetVerTsResult()
+	},
+	"Increment": func() (interface{}, interface{}) {
+		return NewIncrementArgs(), NewIncrementResult()
+	},
+	"IncrementRows": func() (interface{}, interface{}) {
+		return NewIncrementRowsArgs(), NewIncrementRowsResult()
+	},
+	"IsTableEnabled": func() (interface{}, interface{}) {
+		return NewIsTableEnabledArgs(), NewIsTableEnabledResult()
+	},
+	"MajorCompact": func() (interface{}, interface{}) {
+		return NewMajorCompactArgs(), NewMajorCompactResult()
+	},
+	"MutateRow": func() (interface{}, interface{}) {
+		return NewMutateRowArgs(), NewMutateRowResult()
+	},
+	"MutateRowTs": func() (interface{}, interface{}) {
+		return NewMutateRowTsArgs(), NewMutateRowTsResult()
+	},
+	"MutateRows": func() (interface{}, interface{}) {
+		return NewMutateRowsArgs(), NewMutateRowsResult()
+	},
+	"MutateRowsTs": func() (interface{}, interface{}) {
+		return NewMutateRowsTsArgs(), NewMutateRowsTsResult()
+	},
+	"ScannerClose": func() (interface{}, interface{}) {
+		return NewScannerCloseArgs(), NewScannerCloseResult()
+	},
+	"ScannerGet": func() (interface{}, interface{}) {
+		return NewScannerGetArgs(), NewScannerGetResult()
+	},
+	"ScannerGetList": func() (interface{}, interface{}) {
+		return NewScannerGetListArgs(), NewScannerGetListResult()
+	},
+	"ScannerOpen": func() (interface{}, interface{}) {
+		return NewScannerOpenArgs(), NewScannerOpenResult()
+	},
+	"ScannerOpenTs": func() (interface{}, interface{}) {
+		return NewScannerOpenTsArgs(), NewScannerOpenTsResult()
+	},
+	"ScannerOpenWithPrefix": func() (interface{}, interface{}) {
+		return NewScannerOpenWithPrefixArgs(), NewScannerOpenWithPrefixResult()
+	},
+	"ScannerOpenWithScan": func() (interface{}, interface{}) {
+		return NewScannerOpenWithScanArgs(), NewScannerOpenWithScanResult()
+	},
+	"ScannerOpenWithStop": func() (interface{}, interface{}) {
+		return NewScannerOpenWithStopArgs(), NewScannerOpenWithStopResult()
+	},
+	"ScannerOpenWithStopTs": func() (interface{}, interface{}) {
+		return NewScannerOpenWithStopTsArgs(), NewScannerOpenWithStopTsResult()
+	},
+}
+
+// NewCall returns empty thrift args and result structs of method, e.g.
+// *GetArgs and *GetResult for Get. ok is false for unknown methods.
+func NewCall(method string) (args, result interface{}, ok bool) {
+	newCall, ok := calls[method]
+	if !ok {
+		return nil, nil, false
+	}
+	args, result = newCall()
+	return args, result, true
+}
@@ -0,0 +1,245 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	// defaultChunkBytes is the default estimated size of a chunk
+	defaultChunkBytes = 4 << 20
+	// defaultChunkConcurrency is the default number of chunks in flight
+	defaultChunkConcurrency = 4
+	// mutationOverhead estimates the thrift framing of a mutation or a row
+	// key on top of its bytes
+	mutationOverhead = 16
+)
+
+// ChunkOptions configures GetRowsInChunks and MutateRowsInChunks.
+type ChunkOptions struct {
+	// MaxRows is the maximum number of rows per call, 1000 if zero
+	MaxRows int
+	// MaxBytes is the maximum estimated request size per call, 4MB if
+	// zero. A row larger than MaxBytes is sent alone.
+	MaxBytes int
+	// Concurrency is the maximum number of calls in flight, 4 if zero. h
+	// must be safe for concurrent use, e.g. a WrapConn or a Mux, when it
+	// is above one.
+	Concurrency int
+}
+
+func (o ChunkOptions) withDefaults() ChunkOptions {
+	if o.MaxRows <= 0 {
+		o.MaxRows = defaultGetRowsChunk
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = defaultChunkBytes
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultChunkConcurrency
+	}
+	return o
+}
+
+// RowError is the failure of the row at Index of the input of a chunked
+// call.
+type RowError struct {
+	Index int
+	Row   []byte
+	Err   error
+}
+
+// PartialError reports the rows of a chunked call whose chunk failed. The
+// other rows succeeded, so only the failed ones need to be retried.
+type PartialError struct {
+	// Rows is the total number of input rows
+	Rows int
+	// Failed lists the failed rows in input order
+	Failed []RowError
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d of %d rows failed, row %s: %v",
+		len(e.Failed), e.Rows, ToStringBinary(e.Failed[0].Row), e.Failed[0].Err)
+}
+
+// Unwrap returns the distinct errors of the failed rows.
+func (e *PartialError) Unwrap() []error {
+	var errs []error
+	seen := map[error]bool{}
+	for _, f := range e.Failed {
+		if !seen[f.Err] {
+			seen[f.Err] = true
+			errs = append(errs, f.Err)
+		}
+	}
+	return errs
+}
+
+// Indexes returns the input indexes of the failed rows, e.g. to select the
+// BatchMutations to retry.
+func (e *PartialError) Indexes() []int {
+	indexes := make([]int, len(e.Failed))
+	for i, f := range e.Failed {
+		indexes[i] = f.Index
+	}
+	return indexes
+}
+
+// chunkRanges splits n items into ranges of at most maxRows items and at
+// most maxBytes estimated bytes, sizeOf estimating the size of an item.
+func chunkRanges(n, maxRows, maxBytes int, sizeOf func(int) int) [][2]int {
+	var ranges [][2]int
+	start, size := 0, 0
+	for i := 0; i < n; i++ {
+		s := sizeOf(i)
+		if i > start && (i-start >= maxRows || size+s > maxBytes) {
+			ranges = append(ranges, [2]int{start, i})
+			start, size = i, 0
+		}
+		size += s
+	}
+	if start < n {
+		ranges = append(ranges, [2]int{start, n})
+	}
+	return ranges
+}
+
+// chunkAfter returns for every range the index of the last earlier range
+// sharing a row with it, or -1 if none does.
+func chunkAfter(rows [][]byte, ranges [][2]int) []int {
+	after := make([]int, len(ranges))
+	last := map[string]int{}
+	for i, r := range ranges {
+		after[i] = -1
+		for _, row := range rows[r[0]:r[1]] {
+			if j, ok := last[string(row)]; ok && j != i && j > after[i] {
+				after[i] = j
+			}
+			last[string(row)] = i
+		}
+	}
+	return after
+}
+
+// runChunks calls fn for every range and its index, with at most
+// concurrency calls in flight, and returns the error of every range in
+// order. A range whose after entry is not negative is called once that
+// earlier range completed; after may be nil. Ranges not started when ctx
+// is done fail with the error of ctx.
+func runChunks(ctx context.Context, ranges [][2]int, after []int, concurrency int, fn func(chunk, start, end int) error) []error {
+	errs := make([]error, len(ranges))
+	done := make([]chan struct{}, len(ranges))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, r := range ranges {
+		done[i] = make(chan struct{})
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			close(done[i])
+			continue
+		}
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer func() {
+				close(done[i])
+				<-sem
+				wg.Done()
+			}()
+			// the earlier range was started, so it holds or released its
+			// slot and waiting for it cannot starve it
+			if after != nil && after[i] >= 0 {
+				<-done[after[i]]
+			}
+			errs[i] = fn(i, start, end)
+		}(i, r[0], r[1])
+	}
+	wg.Wait()
+	return errs
+}
+
+// partialError collects the rows of the failed ranges, nil if none failed.
+func partialError(rows [][]byte, ranges [][2]int, errs []error) error {
+	e := &PartialError{Rows: len(rows)}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		for index := ranges[i][0]; index < ranges[i][1]; index++ {
+			e.Failed = append(e.Failed, RowError{Index: index, Row: rows[index], Err: err})
+		}
+	}
+	if len(e.Failed) == 0 {
+		return nil
+	}
+	return e
+}
+
+// GetRowsInChunks fetches rows of table with GetRows calls, or
+// GetRowsWithColumns if columns is not empty, over chunks bounded by opts
+// and run concurrently. The results are in the order of rows; like
+// GetRows, rows which do not exist have none. If some chunks fail, the
+// results of the others are returned with a *PartialError listing the rows
+// of the failed ones.
+func GetRowsInChunks(ctx context.Context, h Hbase, table Text, rows, columns [][]byte, opts ChunkOptions) ([]*TRowResult_, error) {
+	opts = opts.withDefaults()
+	h, err := contextHbase(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	ranges := chunkRanges(len(rows), opts.MaxRows, opts.MaxBytes, func(i int) int {
+		return len(rows[i]) + mutationOverhead
+	})
+	chunks := make([][]*TRowResult_, len(ranges))
+	errs := runChunks(ctx, ranges, nil, opts.Concurrency, func(chunk, start, end int) error {
+		var err error
+		if len(columns) > 0 {
+			chunks[chunk], err = h.GetRowsWithColumns(table, rows[start:end], columns, nil)
+		} else {
+			chunks[chunk], err = h.GetRows(table, rows[start:end], nil)
+		}
+		return err
+	})
+	var results []*TRowResult_
+	for _, chunk := range chunks {
+		results = append(results, chunk...)
+	}
+	return results, partialError(rows, ranges, errs)
+}
+
+// MutateRowsInChunks applies rowBatches to table with MutateRows calls, or
+// MutateRowsTs if timestamp is not zero, over chunks bounded by opts and
+// run concurrently. Chunks holding batches of the same row run one after
+// the other, so the batches of a row are applied in input order. If some
+// chunks fail, it returns a *PartialError listing the batches of the
+// failed ones, whose Indexes select the batches to retry.
+func MutateRowsInChunks(ctx context.Context, h Hbase, table Text, rowBatches []*BatchMutation, timestamp int64, opts ChunkOptions) error {
+	opts = opts.withDefaults()
+	h, err := contextHbase(ctx, h)
+	if err != nil {
+		return err
+	}
+	rows := make([][]byte, len(rowBatches))
+	for i, b := range rowBatches {
+		rows[i] = b.Row
+	}
+	ranges := chunkRanges(len(rowBatches), opts.MaxRows, opts.MaxBytes, func(i int) int {
+		size := len(rowBatches[i].Row) + mutationOverhead
+		for _, m := range rowBatches[i].Mutations {
+			size += len(m.Column) + len(m.Value) + mutationOverhead
+		}
+		return size
+	})
+	errs := runChunks(ctx, ranges, chunkAfter(rows, ranges), opts.Concurrency, func(_, start, end int) error {
+		if timestamp == 0 {
+			return h.MutateRows(table, rowBatches[start:end], nil)
+		}
+		return h.MutateRowsTs(table, rowBatches[start:end], timestamp, nil)
+	})
+	return partialError(rows, ranges, errs)
+}
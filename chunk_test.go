@@ -0,0 +1,130 @@
+package hbase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChunkedCalls(t *testing.T) {
+	ctx := context.Background()
+	m := newMemHbase()
+	m.createTable("t")
+
+	// fail every call whose chunk holds row "bad", and record chunk sizes
+	errBad := errors.New("bad chunk")
+	var mu sync.Mutex
+	sizes := map[string][]int{}
+	h := Intercept(m, func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		var rows [][]byte
+		switch a := args.(type) {
+		case *GetRowsArgs:
+			rows = a.Rows
+		case *MutateRowsArgs:
+			for _, b := range a.RowBatches {
+				rows = append(rows, b.Row)
+			}
+		}
+		mu.Lock()
+		sizes[method] = append(sizes[method], len(rows))
+		mu.Unlock()
+		for _, row := range rows {
+			if bytes.Equal(row, []byte("bad")) {
+				return errBad
+			}
+		}
+		return invoker(ctx, method, args, result)
+	})
+
+	var batches []*BatchMutation
+	for i := 0; i < 10; i++ {
+		batches = append(batches, &BatchMutation{Row: Text(fmt.Sprintf("row%d", i)), Mutations: []*Mutation{{Column: Text("cf:a"), Value: Text("v")}}})
+	}
+	batches[7].Row = Text("bad")
+	err := MutateRowsInChunks(ctx, h, Text("t"), batches, 0, ChunkOptions{MaxRows: 3, Concurrency: 2})
+	var partial *PartialError
+	if !errors.As(err, &partial) || !errors.Is(err, errBad) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(partial.Indexes(), []int{6, 7, 8}) || partial.Rows != 10 {
+		t.Fatalf("unexpected failed rows %+v", partial.Failed)
+	}
+	if len(sizes["MutateRows"]) != 4 {
+		t.Fatalf("unexpected chunks %v", sizes)
+	}
+
+	var keys [][]byte
+	for i := 9; i >= 0; i-- {
+		keys = append(keys, []byte(fmt.Sprintf("row%d", i)))
+	}
+	keys[4] = []byte("bad")
+	// each key is estimated at 20 bytes, so chunks hold two keys
+	results, err := GetRowsInChunks(ctx, h, Text("t"), keys, nil, ChunkOptions{MaxBytes: 45})
+	if !errors.As(err, &partial) || !reflect.DeepEqual(partial.Indexes(), []int{4, 5}) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var rows []string
+	for _, r := range results {
+		rows = append(rows, string(r.Row))
+	}
+	// row8, row7 and row6 were never written
+	if !reflect.DeepEqual(rows, []string{"row9", "row3", "row2", "row1", "row0"}) {
+		t.Fatalf("unexpected rows %q", rows)
+	}
+	if len(sizes["GetRows"]) != 5 {
+		t.Fatalf("unexpected chunks %v", sizes)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := GetRowsInChunks(cancelled, h, Text("t"), keys, nil, ChunkOptions{}); err != context.Canceled {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestMutateRowsInChunksOrder(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("t")
+
+	// record the order values reach row "dup", with jitter so that
+	// concurrent chunks interleave
+	var mu sync.Mutex
+	var order []string
+	h := Intercept(m, func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		for _, b := range args.(*MutateRowsArgs).RowBatches {
+			if string(b.Row) == "dup" {
+				mu.Lock()
+				order = append(order, string(b.Mutations[0].Value))
+				mu.Unlock()
+			}
+		}
+		return invoker(ctx, method, args, result)
+	})
+
+	var batches []*BatchMutation
+	var want []string
+	for i := 0; i < 40; i++ {
+		row, value := fmt.Sprintf("row%d", i), fmt.Sprint(i)
+		if i%3 == 0 {
+			row = "dup"
+			want = append(want, value)
+		}
+		batches = append(batches, &BatchMutation{Row: Text(row), Mutations: []*Mutation{{Column: Text("cf:a"), Value: Text(value)}}})
+	}
+	if err := MutateRowsInChunks(context.Background(), h, Text("t"), batches, 0, ChunkOptions{MaxRows: 2, Concurrency: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("unexpected order %v", order)
+	}
+	if cells, err := m.Get(Text("t"), Text("dup"), Text("cf:a"), nil); err != nil || string(cells[0].Value) != want[len(want)-1] {
+		t.Fatalf("unexpected cells %v, %v", cells, err)
+	}
+}
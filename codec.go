@@ -0,0 +1,309 @@
+package hbase
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// Codec encodes values to and decodes values from cell bytes.
+type Codec interface {
+	// Encode returns the cell bytes of v
+	Encode(v interface{}) ([]byte, error)
+	// Decode decodes data into v, which must be a pointer
+	Decode(data []byte, v interface{}) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		"bytes":   BytesCodec,
+		"string":  StringCodec,
+		"int64":   Int64Codec,
+		"int32":   Int32Codec,
+		"float64": Float64Codec,
+		"float32": Float32Codec,
+		"json":    JSONCodec,
+	}
+)
+
+// RegisterCodec registers a codec under name, replacing any codec
+// registered under the same name. Packages providing codecs, such as
+// hbasecodec, register them when imported.
+func RegisterCodec(name string, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = c
+}
+
+// LookupCodec returns the codec registered under name.
+func LookupCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	return c, ok
+}
+
+var (
+	// BytesCodec stores []byte and string values as is
+	BytesCodec Codec = bytesCodec{}
+	// StringCodec stores strings as is and numbers as decimal text
+	StringCodec Codec = stringCodec{}
+	// Int64Codec stores integers as 8 big-endian bytes, the format of the
+	// counters of AtomicIncrement
+	Int64Codec Codec = intCodec{size: 8}
+	// Int32Codec stores integers as 4 big-endian bytes
+	Int32Codec Codec = intCodec{size: 4}
+	// Float64Codec stores floats as 8 big-endian IEEE 754 bytes
+	Float64Codec Codec = floatCodec{size: 8}
+	// Float32Codec stores floats as 4 big-endian IEEE 754 bytes
+	Float32Codec Codec = floatCodec{size: 4}
+	// JSONCodec stores values as JSON
+	JSONCodec Codec = jsonCodec{}
+)
+
+type bytesCodec struct{}
+
+func (bytesCodec) Encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as bytes", v)
+}
+
+func (bytesCodec) Decode(data []byte, v interface{}) error {
+	switch v := v.(type) {
+	case *[]byte:
+		*v = append((*v)[:0], data...)
+		return nil
+	case *string:
+		*v = string(data)
+		return nil
+	}
+	return fmt.Errorf("cannot decode bytes into %T", v)
+}
+
+type stringCodec struct{}
+
+func (stringCodec) Encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(nil, rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.AppendUint(nil, rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.AppendFloat(nil, rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as a string", v)
+}
+
+func (stringCodec) Decode(data []byte, v interface{}) error {
+	switch v := v.(type) {
+	case *[]byte:
+		*v = append((*v)[:0], data...)
+		return nil
+	case *string:
+		*v = string(data)
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cannot decode a string into %T", v)
+	}
+	e := rv.Elem()
+	switch e.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(string(data), 10, e.Type().Bits())
+		if err != nil {
+			return err
+		}
+		e.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(string(data), 10, e.Type().Bits())
+		if err != nil {
+			return err
+		}
+		e.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(string(data), e.Type().Bits())
+		if err != nil {
+			return err
+		}
+		e.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot decode a string into %T", v)
+	}
+	return nil
+}
+
+// intCodec stores integers of any kind as signed size big-endian bytes
+type intCodec struct {
+	size int
+}
+
+func (c intCodec) Encode(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	var n uint64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if c.size == 4 && (rv.Int() < math.MinInt32 || rv.Int() > math.MaxInt32) {
+			return nil, fmt.Errorf("value %d overflows int%d", rv.Int(), 8*c.size)
+		}
+		n = uint64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if (c.size == 4 && rv.Uint() > math.MaxInt32) || rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("value %d overflows int%d", rv.Uint(), 8*c.size)
+		}
+		n = rv.Uint()
+	default:
+		return nil, fmt.Errorf("cannot encode %T as int%d", v, 8*c.size)
+	}
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b[8-c.size:], nil
+}
+
+func (c intCodec) Decode(data []byte, v interface{}) error {
+	if len(data) != c.size {
+		return fmt.Errorf("int%d value of %d bytes", 8*c.size, len(data))
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cannot decode int%d into %T", 8*c.size, v)
+	}
+	e := rv.Elem()
+	var n int64
+	if c.size == 8 {
+		n = int64(binary.BigEndian.Uint64(data))
+	} else {
+		n = int64(int32(binary.BigEndian.Uint32(data)))
+	}
+	switch e.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if e.OverflowInt(n) {
+			return fmt.Errorf("int%d value %d overflows %T", 8*c.size, n, v)
+		}
+		e.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n < 0 || e.OverflowUint(uint64(n)) {
+			return fmt.Errorf("int%d value %d overflows %T", 8*c.size, n, v)
+		}
+		e.SetUint(uint64(n))
+	default:
+		return fmt.Errorf("cannot decode int%d into %T", 8*c.size, v)
+	}
+	return nil
+}
+
+// floatCodec stores floats as size big-endian IEEE 754 bytes
+type floatCodec struct {
+	size int
+}
+
+func (c floatCodec) Encode(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Float32 && rv.Kind() != reflect.Float64 {
+		return nil, fmt.Errorf("cannot encode %T as float%d", v, 8*c.size)
+	}
+	b := make([]byte, c.size)
+	if c.size == 8 {
+		binary.BigEndian.PutUint64(b, math.Float64bits(rv.Float()))
+	} else {
+		binary.BigEndian.PutUint32(b, math.Float32bits(float32(rv.Float())))
+	}
+	return b, nil
+}
+
+func (c floatCodec) Decode(data []byte, v interface{}) error {
+	if len(data) != c.size {
+		return fmt.Errorf("float%d value of %d bytes", 8*c.size, len(data))
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() ||
+		(rv.Elem().Kind() != reflect.Float32 && rv.Elem().Kind() != reflect.Float64) {
+		return fmt.Errorf("cannot decode float%d into %T", 8*c.size, v)
+	}
+	if c.size == 8 {
+		rv.Elem().SetFloat(math.Float64frombits(binary.BigEndian.Uint64(data)))
+	} else {
+		rv.Elem().SetFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(data))))
+	}
+	return nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// columnCodecs maps columns and families to codecs
+type columnCodecs struct {
+	mu sync.RWMutex
+	m  map[string]Codec
+}
+
+// codec returns the codec of a family:qualifier column, falling back to the
+// codec of its family and to BytesCodec.
+func (cc *columnCodecs) codec(column Text) Codec {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	if c, ok := cc.m[string(column)]; ok {
+		return c
+	}
+	if c, ok := cc.m[familyOf(column)]; ok {
+		return c
+	}
+	return BytesCodec
+}
+
+// SetColumnCodec sets the codec GetValue and PutValue use for a
+// family:qualifier column, or for all columns of a family.
+func (c *WrapConn) SetColumnCodec(column string, codec Codec) {
+	c.codecs.mu.Lock()
+	defer c.codecs.mu.Unlock()
+	if c.codecs.m == nil {
+		c.codecs.m = map[string]Codec{}
+	}
+	c.codecs.m[column] = codec
+}
+
+// GetValue reads the latest cell of column and decodes it into v with the
+// codec of the column. It returns false if the cell does not exist.
+func (c *WrapConn) GetValue(tableName, row, column Text, v interface{}) (bool, error) {
+	cells, err := c.Get(tableName, row, column, nil)
+	if err != nil || len(cells) == 0 {
+		return false, err
+	}
+	return true, c.codecs.codec(column).Decode(cells[0].Value, v)
+}
+
+// PutValue encodes v with the codec of column and writes it.
+func (c *WrapConn) PutValue(tableName, row, column Text, v interface{}) error {
+	value, err := c.codecs.codec(column).Encode(v)
+	if err != nil {
+		return err
+	}
+	m := NewMutation()
+	m.Column = column
+	m.Value = value
+	return c.MutateRow(tableName, row, []*Mutation{m}, nil)
+}
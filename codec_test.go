@@ -0,0 +1,139 @@
+package hbase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCodecs(t *testing.T) {
+	// AtomicIncrement counters are 8 big-endian bytes
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(42))
+	if b, err := Int64Codec.Encode(42); err != nil || !bytes.Equal(b, counter) {
+		t.Fatalf("unexpected counter encoding %x: %v", b, err)
+	}
+	var n int64
+	if err := Int64Codec.Decode(counter, &n); err != nil || n != 42 {
+		t.Fatalf("unexpected counter %d: %v", n, err)
+	}
+	var small int8
+	if err := Int64Codec.Decode(counter, &small); err != nil || small != 42 {
+		t.Fatalf("unexpected counter %d: %v", small, err)
+	}
+	if err := Int64Codec.Decode(counter[:4], &n); err == nil {
+		t.Fatal("expected a size error")
+	}
+
+	var i32 int32
+	b, _ := Int32Codec.Encode(int32(-2))
+	if err := Int32Codec.Decode(b, &i32); err != nil || i32 != -2 || len(b) != 4 {
+		t.Fatalf("unexpected int32 %d: %v", i32, err)
+	}
+	// boundaries of the encoded ranges round trip
+	for _, tc := range []struct {
+		codec Codec
+		value interface{}
+	}{
+		{Int32Codec, int32(math.MaxInt32)},
+		{Int32Codec, int32(math.MinInt32)},
+		{Int32Codec, uint32(math.MaxInt32)},
+		{Int64Codec, int64(math.MaxInt64)},
+		{Int64Codec, int64(math.MinInt64)},
+		{Int64Codec, uint64(math.MaxInt64)},
+	} {
+		b, err := tc.codec.Encode(tc.value)
+		if err != nil {
+			t.Fatalf("encode %v: %v", tc.value, err)
+		}
+		decoded := reflect.New(reflect.TypeOf(tc.value))
+		if err := tc.codec.Decode(b, decoded.Interface()); err != nil || decoded.Elem().Interface() != tc.value {
+			t.Fatalf("unexpected round trip of %v: %v, %v", tc.value, decoded.Elem(), err)
+		}
+	}
+	for _, v := range []interface{}{int64(1) << 40, int64(math.MinInt32) - 1, uint32(math.MaxInt32) + 1, uint64(math.MaxUint32)} {
+		if b, err := Int32Codec.Encode(v); err == nil {
+			t.Fatalf("expected %v to overflow int32, got %x", v, b)
+		}
+	}
+	if b, err := Int64Codec.Encode(uint64(math.MaxInt64) + 1); err == nil {
+		t.Fatalf("expected overflow of int64, got %x", b)
+	}
+	var u32 uint32
+	b, _ = Int32Codec.Encode(int32(-1))
+	if err := Int32Codec.Decode(b, &u32); err == nil {
+		t.Fatalf("expected -1 to overflow uint32, got %d", u32)
+	}
+	var f float64
+	b, _ = Float64Codec.Encode(math.Pi)
+	if err := Float64Codec.Decode(b, &f); err != nil || f != math.Pi {
+		t.Fatalf("unexpected float %v: %v", f, err)
+	}
+
+	b, _ = StringCodec.Encode(uint16(65535))
+	var u uint16
+	if err := StringCodec.Decode(b, &u); err != nil || u != 65535 || string(b) != "65535" {
+		t.Fatalf("unexpected decimal %d: %v", u, err)
+	}
+	if err := StringCodec.Decode([]byte("70000"), &u); err == nil {
+		t.Fatal("expected an overflow error")
+	}
+
+	type doc struct {
+		Name string `json:"name"`
+	}
+	var d doc
+	b, _ = JSONCodec.Encode(doc{Name: "x"})
+	if err := JSONCodec.Decode(b, &d); err != nil || d.Name != "x" {
+		t.Fatalf("unexpected doc %+v: %v", d, err)
+	}
+	if c, ok := LookupCodec("int64"); !ok || c != Int64Codec {
+		t.Fatal("int64 codec is not registered")
+	}
+}
+
+func TestWrapConnValues(t *testing.T) {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, 7)
+	mockServer := &MockHbase{}
+	mockServer.On("Get", Text("table"), Text("row"), Text("cf:count"), mock.Anything).
+		Return([]*TCell{{Value: counter}}, nil)
+	mockServer.On("Get", Text("table"), Text("row"), Text("cf:missing"), mock.Anything).
+		Return([]*TCell{}, nil)
+	mockServer.On("MutateRow", Text("table"), Text("row"), mock.Anything, mock.Anything).
+		Return(nil)
+
+	srv, err := NewHbaseServer(mockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	client, err := ThriftClientFactory(fmt.Sprintf("127.0.0.1:%d", srv.Port))()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := NewConn(client)
+	defer client.Close()
+	conn.SetColumnCodec("cf", Int64Codec)
+	conn.SetColumnCodec("cf:doc", JSONCodec)
+
+	var n int
+	if ok, err := conn.GetValue(Text("table"), Text("row"), Text("cf:count"), &n); !ok || err != nil || n != 7 {
+		t.Fatalf("unexpected value %d, %v: %v", n, ok, err)
+	}
+	if ok, err := conn.GetValue(Text("table"), Text("row"), Text("cf:missing"), &n); ok || err != nil {
+		t.Fatalf("unexpected value %d, %v: %v", n, ok, err)
+	}
+	if err := conn.PutValue(Text("table"), Text("row"), Text("cf:doc"), map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	mutations := mockServer.Calls[len(mockServer.Calls)-1].Arguments.Get(2).([]*Mutation)
+	if string(mutations[0].Value) != `{"a":1}` {
+		t.Fatalf("unexpected mutation %v", mutations[0])
+	}
+}
@@ -0,0 +1,205 @@
+package hbase
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compression identifies a value compression algorithm.
+type Compression byte
+
+const (
+	// CompressNone leaves values uncompressed
+	CompressNone Compression = iota
+	// CompressGzip compresses values with gzip
+	CompressGzip
+	// CompressSnappy compresses values with snappy, see hbasecodec
+	CompressSnappy
+	// CompressZstd compresses values with zstd, see hbasecodec
+	CompressZstd
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressNone:
+		return "none"
+	case CompressGzip:
+		return "gzip"
+	case CompressSnappy:
+		return "snappy"
+	case CompressZstd:
+		return "zstd"
+	}
+	return fmt.Sprintf("Compression(%d)", byte(c))
+}
+
+// Compressed values start with a header byte of compressionHeader plus
+// their Compression. Uncompressed values starting with a byte in that
+// range are escaped with the CompressNone header so that compressed and
+// uncompressed values can be told apart.
+const (
+	compressionHeader    = 0xF8
+	maxCompressionHeader = compressionHeader + byte(CompressZstd)
+)
+
+// MaxDecompressedSize is the largest value a Compressor decompresses, well
+// above the default HBase cell size limit of 10MB. Larger values are
+// rejected with ErrDecompressedSize rather than exhausting memory.
+const MaxDecompressedSize = 64 << 20
+
+// ErrDecompressedSize is returned, wrapped, when a value decompresses to
+// more than MaxDecompressedSize bytes.
+var ErrDecompressedSize = fmt.Errorf("value decompresses to more than %d bytes", MaxDecompressedSize)
+
+// Compressor implements a compression algorithm. It must be safe for
+// concurrent use, and Decompress must fail with ErrDecompressedSize rather
+// than return more than MaxDecompressedSize bytes.
+type Compressor interface {
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte) ([]byte, error)
+}
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[Compression]Compressor{
+		CompressGzip: gzipCompressor{},
+	}
+)
+
+// RegisterCompressor registers the Compressor of an algorithm. Gzip is
+// built in; importing hbasecodec registers snappy and zstd.
+func RegisterCompressor(c Compression, comp Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[c] = comp
+}
+
+func lookupCompressor(c Compression) (Compressor, error) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	comp, ok := compressors[c]
+	if !ok {
+		return nil, fmt.Errorf("no %v compressor registered", c)
+	}
+	return comp, nil
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(src []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(io.LimitReader(r, MaxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > MaxDecompressedSize {
+		return nil, ErrDecompressedSize
+	}
+	return b, nil
+}
+
+// CompressionRule selects the compression of the values of a table or
+// family.
+type CompressionRule struct {
+	// Table and Family select the columns of the rule, empty matches all
+	Table  string
+	Family string
+	// Algorithm compresses the values of the columns
+	Algorithm Compression
+	// MinSize is the size under which values are left uncompressed
+	MinSize int
+}
+
+func (r *CompressionRule) matches(table, column Text) bool {
+	return (r.Table == "" || r.Table == string(table)) &&
+		(r.Family == "" || r.Family == familyOf(column))
+}
+
+// compressValue compresses value according to rule and adds its header.
+// Values which compression does not shrink are left uncompressed.
+func compressValue(rule *CompressionRule, value []byte) ([]byte, error) {
+	if rule.Algorithm != CompressNone && len(value) >= rule.MinSize {
+		comp, err := lookupCompressor(rule.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		compressed, err := comp.Compress(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed)+1 < len(value) {
+			return append([]byte{compressionHeader + byte(rule.Algorithm)}, compressed...), nil
+		}
+	}
+	if len(value) > 0 && value[0] >= compressionHeader && value[0] <= maxCompressionHeader {
+		return append([]byte{compressionHeader}, value...), nil
+	}
+	return value, nil
+}
+
+// decompressValue decodes a value written by compressValue. Values without
+// a header are returned as is.
+func decompressValue(value []byte) ([]byte, error) {
+	if len(value) == 0 || value[0] < compressionHeader || value[0] > maxCompressionHeader {
+		return value, nil
+	}
+	algorithm := Compression(value[0] - compressionHeader)
+	if algorithm == CompressNone {
+		return value[1:], nil
+	}
+	comp, err := lookupCompressor(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return comp.Decompress(value[1:])
+}
+
+// CompressionInterceptor compresses the values of the columns selected by
+// rules when they are written with MutateRow*, MutateRows* or CheckAndPut
+// and decompresses them in the results of Get*, GetRow* and scanners. The
+// first matching rule applies; columns matching no rule are left alone.
+//
+// Values are prefixed with a header byte in 0xF8-0xFB, so that compressed
+// and uncompressed values can live in the same column, as long as values
+// written by other clients do not start with such a byte. Appends and
+// CheckAndPut value comparisons are rejected on compressed columns.
+func CompressionInterceptor(rules ...CompressionRule) Interceptor {
+	rule := func(table, column Text) *CompressionRule {
+		for i := range rules {
+			if rules[i].matches(table, column) {
+				return &rules[i]
+			}
+		}
+		return nil
+	}
+	t := newValueTransform("compression")
+	t.manages = func(table, column Text) bool {
+		return rule(table, column) != nil
+	}
+	t.encode = func(ctx context.Context, table, row, column Text, value []byte) ([]byte, error) {
+		return compressValue(rule(table, column), value)
+	}
+	t.decode = func(ctx context.Context, table, row, column Text, value []byte) ([]byte, error) {
+		return decompressValue(value)
+	}
+	return t.interceptor()
+}
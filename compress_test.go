@@ -0,0 +1,94 @@
+package hbase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompressionInterceptor(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("table")
+	h := Intercept(m, CompressionInterceptor(
+		CompressionRule{Table: "table", Family: "raw", Algorithm: CompressNone},
+		CompressionRule{Table: "table", Algorithm: CompressGzip, MinSize: 16},
+	))
+
+	large := strings.Repeat("compressible ", 100)
+	mutations := []*Mutation{
+		{Column: Text("cf:large"), Value: Text(large)},
+		{Column: Text("cf:small"), Value: Text("small")},
+		{Column: Text("raw:header"), Value: Text("\xf9raw")},
+	}
+	if err := h.MutateRows(Text("table"), []*BatchMutation{{Row: Text("row"), Mutations: mutations}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if string(mutations[0].Value) != large {
+		t.Fatal("the caller's mutations were modified")
+	}
+	stored := m.tables["table"].rows["row"]
+	if v := stored["cf:large"][0].Value; v[0] != compressionHeader+byte(CompressGzip) || len(v) >= len(large) {
+		t.Fatalf("large value not compressed: %d bytes", len(v))
+	}
+	if v := stored["cf:small"][0].Value; string(v) != "small" {
+		t.Fatalf("small value was modified: %q", v)
+	}
+	if v := stored["raw:header"][0].Value; string(v) != "\xf8\xf9raw" {
+		t.Fatalf("value with a header byte not escaped: %q", v)
+	}
+
+	rows, err := h.GetRow(Text("table"), Text("row"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rows[0].Columns["cf:large"].Value) != large || string(rows[0].Columns["raw:header"].Value) != "\xf9raw" {
+		t.Fatalf("unexpected row %v", rows[0])
+	}
+
+	// results of the wrapped Hbase are left as they are
+	var raw []*TRowResult_
+	shared := Intercept(m, CompressionInterceptor(CompressionRule{Algorithm: CompressGzip}),
+		func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+			err := invoker(ctx, method, args, result)
+			raw = result.(*GetRowResult).Success
+			return err
+		})
+	rows, err = shared.GetRow(Text("table"), Text("row"), nil)
+	if err != nil || string(rows[0].Columns["cf:large"].Value) != large {
+		t.Fatalf("unexpected rows %v: %v", rows, err)
+	}
+	if rows[0] == raw[0] || raw[0].Columns["cf:large"].Value[0] != compressionHeader+byte(CompressGzip) {
+		t.Fatal("the wrapped result was modified")
+	}
+
+	sorted := true
+	id, err := h.ScannerOpenWithScan(Text("table"), &TScan{SortColumns: &sorted}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err = h.ScannerGetList(id, 10)
+	if err != nil || len(rows) != 1 || !bytes.Equal(rows[0].SortedColumns[0].Cell.Value, []byte(large)) {
+		t.Fatalf("unexpected scanned rows %v: %v", rows, err)
+	}
+	h.ScannerClose(id)
+
+	_, err = h.Append(&TAppend{Table: Text("table"), Row: Text("row"), Columns: [][]byte{[]byte("cf:large")}, Values: [][]byte{[]byte("x")}})
+	if _, ok := err.(*IllegalArgument); !ok {
+		t.Fatalf("expected an append to be rejected, got %v", err)
+	}
+	_, err = h.CheckAndPut(Text("table"), Text("row"), Text("cf:large"), Text(large), &Mutation{Column: Text("cf:large")}, nil)
+	if _, ok := err.(*IllegalArgument); !ok {
+		t.Fatalf("expected a value comparison to be rejected, got %v", err)
+	}
+
+	// values decompressing past the limit are rejected
+	bomb := make([]byte, MaxDecompressedSize+1)
+	if err := h.MutateRow(Text("table"), Text("bomb"), []*Mutation{{Column: Text("cf:bomb"), Value: bomb}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Get(Text("table"), Text("bomb"), Text("cf:bomb"), nil); !errors.Is(err, ErrDecompressedSize) {
+		t.Fatalf("expected a size error, got %v", err)
+	}
+}
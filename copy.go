@@ -0,0 +1,186 @@
+package hbase
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// CopyOptions configures Copy.
+type CopyOptions struct {
+	// StartRow and StopRow restrict the copy to a key range
+	StartRow Text
+	StopRow  Text
+	// Columns restricts the copy to these families or family:qualifier
+	// columns of the source
+	Columns [][]byte
+	// Families renames source families to destination families. Families
+	// missing from the map keep their name.
+	Families map[string]string
+	// Workers is the number of regions copied concurrently, defaults to 1.
+	// The source and destination must be safe for concurrent use, e.g. a
+	// WrapConn or a Mux.
+	Workers int
+	// BatchSize is the number of rows fetched and written per call
+	BatchSize int
+	// Progress, if set, is called after every written batch. Calls are
+	// serialized.
+	Progress func(CopyProgress)
+}
+
+// CopyProgress reports the progress of Copy.
+type CopyProgress struct {
+	// Regions is the number of source regions in the key range, and
+	// RegionsDone the number of them already copied
+	Regions     int
+	RegionsDone int
+	Rows        int
+	Cells       int
+}
+
+// renameFamily renames the family of column according to families.
+func renameFamily(column Text, families map[string]string) Text {
+	i := bytes.IndexByte(column, ':')
+	if i < 0 {
+		return column
+	}
+	family, ok := families[string(column[:i])]
+	if !ok {
+		return column
+	}
+	return append(Text(family), column[i:]...)
+}
+
+// copier tracks the progress of a Copy
+type copier struct {
+	src      Hbase
+	srcTable Text
+	dst      *importer
+	opts     CopyOptions
+
+	mu       sync.Mutex
+	progress CopyProgress
+	err      error
+}
+
+// copyRange copies the rows of a key range.
+func (c *copier) copyRange(r keyRange) error {
+	scan := (&ExportOptions{
+		StartRow:  r.start,
+		StopRow:   r.stop,
+		Columns:   c.opts.Columns,
+		BatchSize: int32(c.opts.BatchSize),
+	}).scan()
+	batch := &importBatch{}
+	err := scanRows(c.src, c.srcTable, scan, int32(c.opts.BatchSize), func(result *TRowResult_) error {
+		row := &importRow{row: result.Row}
+		for _, col := range sortedColumns(result) {
+			row.cells = append(row.cells, importCell{
+				column: renameFamily(col.ColumnName, c.opts.Families),
+				value:  col.Cell.Value,
+				ts:     col.Cell.Timestamp,
+			})
+		}
+		batch.rows = append(batch.rows, row)
+		if len(batch.rows) < c.opts.BatchSize {
+			return nil
+		}
+		if err := c.flush(batch, false); err != nil {
+			return err
+		}
+		batch = &importBatch{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.flush(batch, true)
+}
+
+// flush writes a batch and reports the progress.
+func (c *copier) flush(b *importBatch, regionDone bool) error {
+	if err := c.failed(); err != nil {
+		return err
+	}
+	if len(b.rows) > 0 {
+		if err := c.dst.write(b); err != nil {
+			return err
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.progress.Rows += len(b.rows)
+	for _, r := range b.rows {
+		c.progress.Cells += len(r.cells)
+	}
+	if regionDone {
+		c.progress.RegionsDone++
+	}
+	if c.opts.Progress != nil {
+		c.opts.Progress(c.progress)
+	}
+	return nil
+}
+
+func (c *copier) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *copier) failed() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Copy copies the rows of srcTable to dstTable, on the same or another
+// gateway, keeping the timestamps of the latest version of every cell.
+// Regions of the source are copied by concurrent workers.
+func Copy(src Hbase, srcTable Text, dst Hbase, dstTable Text, opts CopyOptions) (CopyProgress, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultScanBatch
+	}
+	ranges, err := regionRanges(src, srcTable, opts.StartRow, opts.StopRow)
+	if err != nil {
+		return CopyProgress{}, err
+	}
+	c := &copier{
+		src:      src,
+		srcTable: srcTable,
+		dst:      &importer{h: dst, table: dstTable, timestamps: true},
+		opts:     opts,
+		progress: CopyProgress{Regions: len(ranges)},
+	}
+
+	work := make(chan keyRange, len(ranges))
+	for _, r := range ranges {
+		work <- r
+	}
+	close(work)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range work {
+				if c.failed() != nil {
+					return
+				}
+				if err := c.copyRange(r); err != nil {
+					c.fail(fmt.Errorf("range [%q, %q): %v", r.start, r.stop, err))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.progress, c.err
+}
@@ -0,0 +1,60 @@
+package hbase
+
+import (
+	"testing"
+)
+
+func TestRegionRanges(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("table", "b", "d")
+	ranges, err := regionRanges(m, Text("table"), Text("a"), Text("c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 2 ||
+		string(ranges[0].start) != "a" || string(ranges[0].stop) != "b" ||
+		string(ranges[1].start) != "b" || string(ranges[1].stop) != "c" {
+		t.Fatalf("unexpected ranges %v", ranges)
+	}
+	if ranges, _ = regionRanges(m, Text("table"), nil, nil); len(ranges) != 3 || len(ranges[2].stop) != 0 {
+		t.Fatalf("unexpected ranges %v", ranges)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := newMemHbase()
+	src.createTable("table", "m")
+	for _, row := range []string{"a", "k", "n", "z"} {
+		src.put("table", row, "cf:q", row+"1", 10)
+		src.put("table", row, "other:q", row+"2", 20)
+	}
+	dst := newMemHbase()
+	dst.createTable("copy")
+
+	var last CopyProgress
+	progress, err := Copy(src, Text("table"), dst, Text("copy"), CopyOptions{
+		StartRow:  Text("b"),
+		Families:  map[string]string{"cf": "renamed"},
+		Workers:   2,
+		BatchSize: 1,
+		Progress:  func(p CopyProgress) { last = p },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress != last || progress.Regions != 2 || progress.RegionsDone != 2 || progress.Rows != 3 || progress.Cells != 6 {
+		t.Fatalf("unexpected progress %+v, last reported %+v", progress, last)
+	}
+	r, _ := dst.GetRow(Text("copy"), Text("n"), nil)
+	if len(r) != 1 || string(r[0].Columns["renamed:q"].Value) != "n1" ||
+		r[0].Columns["renamed:q"].Timestamp != 10 || r[0].Columns["other:q"].Timestamp != 20 {
+		t.Fatalf("unexpected copied row %v", r)
+	}
+	if r, _ := dst.GetRow(Text("copy"), Text("a"), nil); len(r) != 0 {
+		t.Fatalf("copied row out of range %v", r)
+	}
+
+	if _, err := Copy(src, Text("table"), dst, Text("missing"), CopyOptions{}); err == nil {
+		t.Fatal("expected an error writing to a missing table")
+	}
+}
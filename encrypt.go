@@ -0,0 +1,196 @@
+package hbase
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnauthorized is returned, wrapped, when a caller may not encrypt or
+// decrypt the values of a column.
+var ErrUnauthorized = errors.New("not authorized to access encrypted column")
+
+// encryptionVersion is the first byte of encrypted values
+const encryptionVersion = 1
+
+// KeyProvider supplies the AES keys of an EncryptionInterceptor, e.g. data
+// keys unwrapped with a key management service. Keys are 16, 24 or 32
+// bytes long.
+type KeyProvider interface {
+	// CurrentKey returns the key new values are encrypted with and its id
+	CurrentKey(ctx context.Context) (id string, key []byte, err error)
+	// Key returns the key of an id, to decrypt values encrypted with it
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// StaticKeyProvider is a KeyProvider holding its keys in memory.
+type StaticKeyProvider struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyProvider creates a StaticKeyProvider encrypting with the key
+// id.
+func NewStaticKeyProvider(id string, key []byte) *StaticKeyProvider {
+	return &StaticKeyProvider{current: id, keys: map[string][]byte{id: key}}
+}
+
+// Rotate adds a key and makes it the current key. Values encrypted with
+// previous keys can still be decrypted.
+func (p *StaticKeyProvider) Rotate(id string, key []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[id] = key
+	p.current = id
+}
+
+// CurrentKey implements KeyProvider.
+func (p *StaticKeyProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current, p.keys[p.current], nil
+}
+
+// Key implements KeyProvider.
+func (p *StaticKeyProvider) Key(ctx context.Context, id string) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", id)
+	}
+	return key, nil
+}
+
+// EncryptionConfig configures EncryptionInterceptor.
+//
+// Encrypted values are bound to the table, row and column they are written
+// to, as the interceptor sees them, and fail to decrypt anywhere else. Raw
+// values therefore cannot be moved to a renamed table or another table by
+// Copy or Restore, unless both sides go through an EncryptionInterceptor to
+// decrypt and encrypt them again. Along with a NamespaceInterceptor, tables
+// are bound with their prefix if the EncryptionInterceptor follows it in
+// Intercept and without it otherwise, so the order of the two must not
+// change once values are written.
+type EncryptionConfig struct {
+	// Keys provides the encryption keys
+	Keys KeyProvider
+	// Table restricts encryption to a table, empty applies to all tables
+	Table string
+	// Columns lists the encrypted family:qualifier columns
+	Columns []string
+	// Authorize, if set, reports whether the caller of ctx may access a
+	// column, see InterceptedHbase.WithContext. Unauthorized calls fail
+	// with ErrUnauthorized.
+	Authorize func(ctx context.Context, table, column Text) bool
+}
+
+// newGCM returns the AES-GCM cipher of key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptionAAD returns the additional data authenticated with a value, its
+// length-prefixed table, row and column, so that values cannot be moved to
+// other cells.
+func encryptionAAD(table, row, column []byte) []byte {
+	aad := make([]byte, 0, 3*binary.MaxVarintLen64+len(table)+len(row)+len(column))
+	for _, b := range [][]byte{table, row, column} {
+		aad = binary.AppendUvarint(aad, uint64(len(b)))
+		aad = append(aad, b...)
+	}
+	return aad
+}
+
+// encryptValue encrypts the value of a cell with the current key. The
+// table, row and column of the cell are authenticated. Encrypted values
+// are laid out as:
+//
+//	version (1 byte) | key id length (1 byte) | key id | nonce | sealed value
+func encryptValue(ctx context.Context, keys KeyProvider, table, row, column, value []byte) ([]byte, error) {
+	id, key, err := keys.CurrentKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("key id %q longer than 255 bytes", id)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, 2+len(id)+gcm.NonceSize()+len(value)+gcm.Overhead())
+	out = append(out, encryptionVersion, byte(len(id)))
+	out = append(out, id...)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, value, encryptionAAD(table, row, column)), nil
+}
+
+// decryptValue decrypts a value written by encryptValue.
+func decryptValue(ctx context.Context, keys KeyProvider, table, row, column, value []byte) ([]byte, error) {
+	if len(value) < 2 || value[0] != encryptionVersion || len(value) < 2+int(value[1]) {
+		return nil, errors.New("value is not encrypted")
+	}
+	id, value := string(value[2:2+int(value[1])]), value[2+int(value[1]):]
+	key, err := keys.Key(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) < gcm.NonceSize() {
+		return nil, errors.New("truncated encrypted value")
+	}
+	return gcm.Open(nil, value[:gcm.NonceSize()], value[gcm.NonceSize():], encryptionAAD(table, row, column))
+}
+
+// EncryptionInterceptor encrypts the values of the configured columns with
+// AES-GCM when they are written with MutateRow*, MutateRows* or CheckAndPut
+// and decrypts them in the results of Get*, GetRow* and scanners. Values
+// record the id of their key, so that keys can be rotated. Appends and
+// CheckAndPut value comparisons are rejected on encrypted columns.
+func EncryptionInterceptor(cfg EncryptionConfig) Interceptor {
+	columns := make(map[string]bool, len(cfg.Columns))
+	for _, c := range cfg.Columns {
+		columns[c] = true
+	}
+	authorize := func(ctx context.Context, table, column Text) error {
+		if cfg.Authorize != nil && !cfg.Authorize(ctx, table, column) {
+			return ErrUnauthorized
+		}
+		return nil
+	}
+	t := newValueTransform("encryption")
+	t.manages = func(table, column Text) bool {
+		return (cfg.Table == "" || cfg.Table == string(table)) && columns[string(column)]
+	}
+	t.encode = func(ctx context.Context, table, row, column Text, value []byte) ([]byte, error) {
+		if err := authorize(ctx, table, column); err != nil {
+			return nil, err
+		}
+		return encryptValue(ctx, cfg.Keys, table, row, column, value)
+	}
+	t.decode = func(ctx context.Context, table, row, column Text, value []byte) ([]byte, error) {
+		if err := authorize(ctx, table, column); err != nil {
+			return nil, err
+		}
+		return decryptValue(ctx, cfg.Keys, table, row, column, value)
+	}
+	return t.interceptor()
+}
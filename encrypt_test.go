@@ -0,0 +1,90 @@
+package hbase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+type callerKey struct{}
+
+func TestEncryptionInterceptor(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("table")
+	keys := NewStaticKeyProvider("k1", bytes.Repeat([]byte{1}, 32))
+	h := Intercept(m, EncryptionInterceptor(EncryptionConfig{
+		Keys:    keys,
+		Table:   "table",
+		Columns: []string{"pii:ssn"},
+		Authorize: func(ctx context.Context, table, column Text) bool {
+			return ctx.Value(callerKey{}) == "admin"
+		},
+	}))
+	admin := h.WithContext(context.WithValue(context.Background(), callerKey{}, "admin"))
+
+	write := func(row, value string) {
+		t.Helper()
+		err := admin.MutateRow(Text("table"), Text(row), []*Mutation{
+			{Column: Text("pii:ssn"), Value: Text(value)},
+			{Column: Text("pii:name"), Value: Text(value)},
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("r1", "secret1")
+	keys.Rotate("k2", bytes.Repeat([]byte{2}, 16))
+	write("r2", "secret2")
+
+	stored := m.tables["table"].rows
+	if v := stored["r1"]["pii:ssn"][0].Value; bytes.Contains(v, []byte("secret1")) || string(v[2:4]) != "k1" {
+		t.Fatalf("value not encrypted with k1: %q", v)
+	}
+	if v := stored["r2"]["pii:ssn"][0].Value; string(v[2:4]) != "k2" {
+		t.Fatalf("value not encrypted with k2: %q", v)
+	}
+	if v := stored["r1"]["pii:name"][0].Value; string(v) != "secret1" {
+		t.Fatalf("unconfigured column was encrypted: %q", v)
+	}
+
+	rows, err := admin.GetRows(Text("table"), [][]byte{Text("r1"), Text("r2")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rows[0].Columns["pii:ssn"].Value) != "secret1" || string(rows[1].Columns["pii:ssn"].Value) != "secret2" {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+
+	if _, err := h.GetRow(Text("table"), Text("r1"), nil); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+
+	// a value moved to another column fails authentication
+	stored["r1"]["pii:other"] = stored["r1"]["pii:ssn"]
+	other := Intercept(m, EncryptionInterceptor(EncryptionConfig{Keys: keys, Columns: []string{"pii:other"}}))
+	if _, err := other.GetRow(Text("table"), Text("r1"), nil); err == nil {
+		t.Fatal("expected a decryption error")
+	}
+
+	// a value moved to another row or table fails authentication
+	if cells, err := admin.Get(Text("table"), Text("r1"), Text("pii:ssn"), nil); err != nil || string(cells[0].Value) != "secret1" {
+		t.Fatalf("unexpected cells %v: %v", cells, err)
+	}
+	stored["r2"]["pii:ssn"] = stored["r1"]["pii:ssn"]
+	if _, err := admin.Get(Text("table"), Text("r2"), Text("pii:ssn"), nil); err == nil {
+		t.Fatal("expected a decryption error of a value moved across rows")
+	}
+	if _, err := admin.GetRow(Text("table"), Text("r2"), nil); err == nil {
+		t.Fatal("expected a decryption error of a value moved across rows")
+	}
+	m.createTable("copy")
+	m.tables["copy"].rows["r1"] = map[string][]*TCell{"pii:ssn": stored["r1"]["pii:ssn"]}
+	anyTable := Intercept(m, EncryptionInterceptor(EncryptionConfig{Keys: keys, Columns: []string{"pii:ssn"}}))
+	if _, err := anyTable.Get(Text("table"), Text("r1"), Text("pii:ssn"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := anyTable.GetRow(Text("copy"), Text("r1"), nil); err == nil {
+		t.Fatal("expected a decryption error of a value moved across tables")
+	}
+}
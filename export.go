@@ -0,0 +1,436 @@
+package hbase
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// ExportFormat is the file format of exported rows
+type ExportFormat int
+
+const (
+	// ExportJSON writes a JSON object per row and line
+	ExportJSON ExportFormat = iota
+	// ExportCSV writes a row,column,timestamp,value line per cell
+	ExportCSV
+	// ExportBinary writes TRowResult_ structs with the thrift compact protocol
+	ExportBinary
+)
+
+// ParseExportFormat parses "json", "csv" or "binary".
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch s {
+	case "json", "jsonl":
+		return ExportJSON, nil
+	case "csv":
+		return ExportCSV, nil
+	case "binary", "bin":
+		return ExportBinary, nil
+	}
+	return 0, fmt.Errorf("unknown export format %q", s)
+}
+
+// ext returns the file name extension of the format
+func (f ExportFormat) ext() string {
+	switch f {
+	case ExportCSV:
+		return ".csv"
+	case ExportBinary:
+		return ".bin"
+	}
+	return ".jsonl"
+}
+
+// ValueEncoding is the encoding of row keys, columns and values in the
+// text export formats
+type ValueEncoding int
+
+const (
+	// EncodeBase64 encodes all bytes in standard base64
+	EncodeBase64 ValueEncoding = iota
+	// EncodeHex encodes all bytes in lower case hex
+	EncodeHex
+	// EncodeUTF8 keeps valid UTF-8 as is and encodes other bytes in base64
+	// prefixed with "base64:"
+	EncodeUTF8
+)
+
+// utf8Base64Prefix marks base64 encoded bytes with EncodeUTF8
+const utf8Base64Prefix = "base64:"
+
+// ParseValueEncoding parses "base64", "hex" or "utf8".
+func ParseValueEncoding(s string) (ValueEncoding, error) {
+	switch s {
+	case "base64":
+		return EncodeBase64, nil
+	case "hex":
+		return EncodeHex, nil
+	case "utf8":
+		return EncodeUTF8, nil
+	}
+	return 0, fmt.Errorf("unknown value encoding %q", s)
+}
+
+// encodeValue encodes b to a string with enc.
+func encodeValue(b []byte, enc ValueEncoding) string {
+	switch enc {
+	case EncodeHex:
+		return hex.EncodeToString(b)
+	case EncodeUTF8:
+		if utf8.Valid(b) && !bytes.HasPrefix(b, []byte(utf8Base64Prefix)) {
+			return string(b)
+		}
+		return utf8Base64Prefix + base64.StdEncoding.EncodeToString(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// decodeValue decodes s encoded with enc.
+func decodeValue(s string, enc ValueEncoding) ([]byte, error) {
+	switch enc {
+	case EncodeHex:
+		return hex.DecodeString(s)
+	case EncodeUTF8:
+		if len(s) >= len(utf8Base64Prefix) && s[:len(utf8Base64Prefix)] == utf8Base64Prefix {
+			return base64.StdEncoding.DecodeString(s[len(utf8Base64Prefix):])
+		}
+		return []byte(s), nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// exportRow is a row in the JSON export format
+type exportRow struct {
+	Row   string       `json:"row"`
+	Cells []exportCell `json:"cells"`
+}
+
+// exportCell is a cell in the JSON export format
+type exportCell struct {
+	Column    string `json:"column"`
+	Value     string `json:"value"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// csvHeader is the header of the CSV export format
+var csvHeader = []string{"row", "column", "timestamp", "value"}
+
+// RowWriter writes rows to an export.
+type RowWriter interface {
+	WriteRow(r *TRowResult_) error
+	// Close flushes buffered rows. It does not close the underlying writer.
+	Close() error
+}
+
+// NewRowWriter creates a RowWriter writing rows to w in format, with keys,
+// columns and values of the text formats encoded with enc.
+func NewRowWriter(w io.Writer, format ExportFormat, enc ValueEncoding) RowWriter {
+	switch format {
+	case ExportCSV:
+		return &csvRowWriter{w: csv.NewWriter(w), enc: enc}
+	case ExportBinary:
+		trans := thrift.NewStreamTransportW(w)
+		return &binaryRowWriter{trans: trans, oprot: thrift.NewTCompactProtocol(trans)}
+	}
+	return &jsonRowWriter{w: bufio.NewWriter(w), enc: enc}
+}
+
+// flusher is implemented by the RowWriters of NewRowWriter
+type flusher interface {
+	flush() error
+}
+
+type jsonRowWriter struct {
+	w   *bufio.Writer
+	enc ValueEncoding
+}
+
+func (w *jsonRowWriter) WriteRow(r *TRowResult_) error {
+	row := exportRow{Row: encodeValue(r.Row, w.enc)}
+	for _, col := range sortedColumns(r) {
+		row.Cells = append(row.Cells, exportCell{
+			Column:    encodeValue(col.ColumnName, w.enc),
+			Value:     encodeValue(col.Cell.Value, w.enc),
+			Timestamp: col.Cell.Timestamp,
+		})
+	}
+	buf, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(buf); err != nil {
+		return err
+	}
+	return w.w.WriteByte('\n')
+}
+
+func (w *jsonRowWriter) flush() error {
+	return w.w.Flush()
+}
+
+func (w *jsonRowWriter) Close() error {
+	return w.flush()
+}
+
+type csvRowWriter struct {
+	w      *csv.Writer
+	enc    ValueEncoding
+	header bool
+}
+
+func (w *csvRowWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.w.Write(csvHeader)
+}
+
+func (w *csvRowWriter) WriteRow(r *TRowResult_) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	row := encodeValue(r.Row, w.enc)
+	for _, col := range sortedColumns(r) {
+		if err := w.w.Write([]string{
+			row,
+			encodeValue(col.ColumnName, w.enc),
+			strconv.FormatInt(col.Cell.Timestamp, 10),
+			encodeValue(col.Cell.Value, w.enc),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *csvRowWriter) flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvRowWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+type binaryRowWriter struct {
+	trans *thrift.StreamTransport
+	oprot thrift.TProtocol
+}
+
+func (w *binaryRowWriter) WriteRow(r *TRowResult_) error {
+	return r.Write(w.oprot)
+}
+
+func (w *binaryRowWriter) flush() error {
+	return w.oprot.Flush()
+}
+
+func (w *binaryRowWriter) Close() error {
+	return w.flush()
+}
+
+// SplitWriter is a RowWriter which writes rows to numbered files, starting
+// a new file whenever the current one reaches a row or size limit.
+type SplitWriter struct {
+	prefix   string
+	format   ExportFormat
+	enc      ValueEncoding
+	compress bool
+	maxRows  int
+	maxBytes int64
+
+	files []string
+	file  *os.File
+	gz    *gzip.Writer
+	count *countingWriter
+	rw    RowWriter
+	rows  int
+}
+
+// NewSplitWriter creates a SplitWriter writing files named like
+// prefix-00000.jsonl, gzip compressed and suffixed with .gz if compress is
+// set. A new file is started after maxRows rows or maxBytes uncompressed
+// bytes; zero disables a limit.
+func NewSplitWriter(prefix string, format ExportFormat, enc ValueEncoding, compress bool, maxRows int, maxBytes int64) *SplitWriter {
+	return &SplitWriter{
+		prefix:   prefix,
+		format:   format,
+		enc:      enc,
+		compress: compress,
+		maxRows:  maxRows,
+		maxBytes: maxBytes,
+	}
+}
+
+// Files returns the names of the files written so far.
+func (s *SplitWriter) Files() []string {
+	return append([]string(nil), s.files...)
+}
+
+// WriteRow implements RowWriter.
+func (s *SplitWriter) WriteRow(r *TRowResult_) error {
+	if s.rw != nil && ((s.maxRows > 0 && s.rows >= s.maxRows) ||
+		(s.maxBytes > 0 && s.count.n >= s.maxBytes)) {
+		if err := s.closeFile(); err != nil {
+			return err
+		}
+	}
+	if s.rw == nil {
+		if err := s.openFile(); err != nil {
+			return err
+		}
+	}
+	s.rows++
+	if err := s.rw.WriteRow(r); err != nil {
+		return err
+	}
+	// flush to count the bytes of the row against the size limit
+	if s.maxBytes > 0 {
+		return s.rw.(flusher).flush()
+	}
+	return nil
+}
+
+// Close implements RowWriter. It closes the current file.
+func (s *SplitWriter) Close() error {
+	if s.rw == nil {
+		return nil
+	}
+	return s.closeFile()
+}
+
+func (s *SplitWriter) openFile() error {
+	name := fmt.Sprintf("%s-%05d%s", s.prefix, len(s.files), s.format.ext())
+	if s.compress {
+		name += ".gz"
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	s.files = append(s.files, name)
+	s.file = f
+	var w io.Writer = f
+	if s.compress {
+		s.gz = gzip.NewWriter(f)
+		w = s.gz
+	}
+	s.count = &countingWriter{w: w}
+	s.rw = NewRowWriter(s.count, s.format, s.enc)
+	s.rows = 0
+	return nil
+}
+
+func (s *SplitWriter) closeFile() error {
+	err := s.rw.Close()
+	if s.gz != nil {
+		if gzErr := s.gz.Close(); err == nil {
+			err = gzErr
+		}
+		s.gz = nil
+	}
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.rw = nil
+	return err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ExportOptions selects the rows and cells exported by Export.
+type ExportOptions struct {
+	// StartRow and StopRow limit the exported key range [StartRow, StopRow)
+	StartRow Text
+	StopRow  Text
+	// Prefix limits the export to rows starting with it
+	Prefix Text
+	// Columns limits the export to the given families or family:qualifier
+	Columns [][]byte
+	// FilterString is an HBase filter applied by the scan
+	FilterString Text
+	// Timestamp, if set, exports only cells written before it
+	Timestamp int64
+	// Limit stops the export after this many rows, zero exports all
+	Limit int
+	// BatchSize is the number of rows fetched per round trip
+	BatchSize int32
+}
+
+// scan builds the TScan selecting the rows of the options.
+func (o *ExportOptions) scan() *TScan {
+	scan := &TScan{
+		StartRow:     o.StartRow,
+		StopRow:      o.StopRow,
+		Columns:      o.Columns,
+		FilterString: o.FilterString,
+	}
+	if len(o.Prefix) > 0 {
+		if bytes.Compare(scan.StartRow, o.Prefix) < 0 {
+			scan.StartRow = o.Prefix
+		}
+		if stop := prefixStopRow(o.Prefix); stop != nil &&
+			(len(scan.StopRow) == 0 || bytes.Compare(stop, scan.StopRow) < 0) {
+			scan.StopRow = stop
+		}
+	}
+	if o.Timestamp > 0 {
+		ts := o.Timestamp
+		scan.Timestamp = &ts
+	}
+	batch := o.BatchSize
+	if batch <= 0 {
+		batch = defaultScanBatch
+	}
+	scan.Caching = &batch
+	return scan
+}
+
+// errExportLimit stops a scan once the export limit is reached
+var errExportLimit = fmt.Errorf("export limit reached")
+
+// Export scans table and writes the selected rows to w. It returns the
+// number of rows written. w is not closed.
+func Export(h Hbase, table Text, opts ExportOptions, w RowWriter) (int, error) {
+	n := 0
+	err := scanRows(h, table, opts.scan(), opts.BatchSize, func(r *TRowResult_) error {
+		if err := w.WriteRow(r); err != nil {
+			return err
+		}
+		n++
+		if opts.Limit > 0 && n >= opts.Limit {
+			return errExportLimit
+		}
+		return nil
+	})
+	if err == errExportLimit {
+		err = nil
+	}
+	return n, err
+}
@@ -0,0 +1,84 @@
+package hbase
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+func TestExport(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("table")
+	m.put("table", "a1", "cf:q", "hello", 10)
+	m.put("table", "a2", "cf:q", "\x00\xff", 11)
+	m.put("table", "a2", "cf:r", "base64:x", 12)
+	m.put("table", "b1", "cf:q", "late", 20)
+
+	var buf bytes.Buffer
+	w := NewRowWriter(&buf, ExportJSON, EncodeUTF8)
+	n, err := Export(m, Text("table"), ExportOptions{Prefix: Text("a"), BatchSize: 1}, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"row":"a1","cells":[{"column":"cf:q","value":"hello","timestamp":10}]}
+{"row":"a2","cells":[{"column":"cf:q","value":"base64:AP8=","timestamp":11},{"column":"cf:r","value":"base64:YmFzZTY0Ong=","timestamp":12}]}
+`
+	if n != 2 || buf.String() != expected {
+		t.Fatalf("unexpected export of %d rows:\n%s", n, buf.String())
+	}
+
+	buf.Reset()
+	w = NewRowWriter(&buf, ExportCSV, EncodeHex)
+	if _, err := Export(m, Text("table"), ExportOptions{Timestamp: 11}, w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if buf.String() != "row,column,timestamp,value\n6131,63663a71,10,68656c6c6f\n" {
+		t.Fatalf("unexpected csv export:\n%s", buf.String())
+	}
+
+	// split, compressed binary files
+	dir := t.TempDir()
+	split := NewSplitWriter(filepath.Join(dir, "table"), ExportBinary, EncodeBase64, true, 2, 0)
+	if n, err := Export(m, Text("table"), ExportOptions{}, split); err != nil || n != 3 {
+		t.Fatalf("unexpected export of %d rows: %v", n, err)
+	}
+	if err := split.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files := split.Files()
+	if len(files) != 2 || !strings.HasSuffix(files[1], "table-00001.bin.gz") {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	f, err := os.Open(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer := thrift.NewTMemoryBuffer()
+	buffer.Write(data)
+	r := NewTRowResult_()
+	if err := r.Read(thrift.NewTCompactProtocol(buffer)); err != nil {
+		t.Fatal(err)
+	}
+	if string(r.Row) != "b1" || string(r.Columns["cf:q"].Value) != "late" {
+		t.Fatalf("unexpected row: %v", r)
+	}
+}
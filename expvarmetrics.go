@@ -0,0 +1,109 @@
+package hbase
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the latency histograms
+// kept by ExpvarMetrics.
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// ExpvarMetrics implements Metrics by publishing per-method counters and
+// latency histograms as an expvar map.
+type ExpvarMetrics struct {
+	calls        *expvar.Map
+	errors       *expvar.Map
+	bytesWritten *expvar.Map
+	bytesRead    *expvar.Map
+	rows         *expvar.Map
+	inFlight     *expvar.Map
+
+	// mu guards the creation of per-method histograms in latency
+	mu      sync.Mutex
+	latency *expvar.Map
+}
+
+// NewExpvarMetrics creates an ExpvarMetrics published under name. Like
+// expvar.NewMap, it panics if name is already registered.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	m := &ExpvarMetrics{
+		calls:        new(expvar.Map).Init(),
+		errors:       new(expvar.Map).Init(),
+		bytesWritten: new(expvar.Map).Init(),
+		bytesRead:    new(expvar.Map).Init(),
+		rows:         new(expvar.Map).Init(),
+		inFlight:     new(expvar.Map).Init(),
+		latency:      new(expvar.Map).Init(),
+	}
+	root := expvar.NewMap(name)
+	root.Set("calls", m.calls)
+	root.Set("errors", m.errors)
+	root.Set("bytes_written", m.bytesWritten)
+	root.Set("bytes_read", m.bytesRead)
+	root.Set("rows", m.rows)
+	root.Set("in_flight", m.inFlight)
+	root.Set("latency", m.latency)
+	return m
+}
+
+// histogram returns the latency histogram of method, creating it if needed.
+func (m *ExpvarMetrics) histogram(method string) *expvar.Map {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if h, ok := m.latency.Get(method).(*expvar.Map); ok {
+		return h
+	}
+	h := new(expvar.Map).Init()
+	m.latency.Set(method, h)
+	return h
+}
+
+// ObserveLatency implements Metrics. Buckets are cumulative, i.e. a call is
+// counted in every bucket whose bound is not lower than its duration.
+func (m *ExpvarMetrics) ObserveLatency(method string, d time.Duration) {
+	m.calls.Add(method, 1)
+	h := m.histogram(method)
+	for _, bound := range DefaultLatencyBuckets {
+		if d <= bound {
+			h.Add("le_"+bound.String(), 1)
+		}
+	}
+	h.Add("count", 1)
+	h.Add("sum_us", int64(d/time.Microsecond))
+}
+
+// IncError implements Metrics.
+func (m *ExpvarMetrics) IncError(method, class string) {
+	m.errors.Add(method+"."+class, 1)
+}
+
+// AddBytes implements Metrics.
+func (m *ExpvarMetrics) AddBytes(method string, written, read int) {
+	m.bytesWritten.Add(method, int64(written))
+	m.bytesRead.Add(method, int64(read))
+}
+
+// AddRows implements Metrics.
+func (m *ExpvarMetrics) AddRows(method string, rows int) {
+	m.rows.Add(method, int64(rows))
+}
+
+// AddInFlight implements Metrics.
+func (m *ExpvarMetrics) AddInFlight(method string, delta int) {
+	m.inFlight.Add(method, int64(delta))
+}
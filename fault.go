@@ -0,0 +1,122 @@
+package hbase
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FaultRule describes a fault injected into the calls it matches.
+type FaultRule struct {
+	// Method matches calls by method name, e.g. "GetRow". Empty matches all.
+	Method string
+	// Table matches calls by table name. Empty matches all.
+	Table string
+	// Probability is the chance of the rule firing on a matching call,
+	// between 0, never, and 1, see Chance. Nil fires on every matching
+	// call.
+	Probability *float64
+
+	// Latency is added before the call is performed.
+	Latency time.Duration
+	// Err is returned instead of performing the call, e.g. an *IOError, an
+	// *IllegalArgument or a thrift.TApplicationException. Through a server,
+	// errors a method does not declare reach clients as
+	// TApplicationException.
+	Err error
+
+	// Reset makes a FaultProxy reset the connection after forwarding
+	// ResetAfter bytes of the response. It is ignored by Interceptor.
+	Reset      bool
+	ResetAfter int
+	// Truncate makes a FaultProxy forward only the first half of the
+	// response frame and close the connection. It is ignored by Interceptor.
+	Truncate bool
+}
+
+// Chance returns a FaultRule Probability of p.
+func Chance(p float64) *float64 {
+	return &p
+}
+
+// matches returns if the rule applies to the call.
+func (r *FaultRule) matches(method string, table Text) bool {
+	return (r.Method == "" || r.Method == method) &&
+		(r.Table == "" || r.Table == string(table))
+}
+
+// FaultInjector injects faults into calls by rules. The first matching rule
+// which fires is applied to a call. It is used with NewHbaseServer through
+// Interceptor, e.g.
+//
+//	faults := NewFaultInjector(FaultRule{Method: "GetRow", Latency: time.Second})
+//	srv, err := NewHbaseServer(Intercept(mockServer, faults.Interceptor()))
+//
+// or in front of any server through NewFaultProxy.
+type FaultInjector struct {
+	mu    sync.Mutex
+	rules []FaultRule
+	rand  *rand.Rand
+}
+
+// NewFaultInjector creates a FaultInjector with the given rules.
+func NewFaultInjector(rules ...FaultRule) *FaultInjector {
+	return &FaultInjector{
+		rules: rules,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetRules replaces the rules, e.g. to heal or break a server mid-test.
+func (f *FaultInjector) SetRules(rules ...FaultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = rules
+}
+
+// Seed reseeds the random source deciding if probabilistic rules fire.
+func (f *FaultInjector) Seed(seed int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rand.Seed(seed)
+}
+
+// match returns the rule to apply to a call, or nil.
+func (f *FaultInjector) match(method string, table Text) *FaultRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.rules {
+		r := f.rules[i]
+		if !r.matches(method, table) {
+			continue
+		}
+		if r.Probability != nil && f.rand.Float64() >= *r.Probability {
+			continue
+		}
+		return &r
+	}
+	return nil
+}
+
+// Interceptor returns an Interceptor which applies the latency and errors
+// of the rules.
+func (f *FaultInjector) Interceptor() Interceptor {
+	return func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		r := f.match(method, TableOf(args))
+		if r == nil {
+			return invoker(ctx, method, args, result)
+		}
+		if r.Latency > 0 {
+			select {
+			case <-time.After(r.Latency):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if r.Err != nil {
+			return r.Err
+		}
+		return invoker(ctx, method, args, result)
+	}
+}
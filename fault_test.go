@@ -0,0 +1,120 @@
+package hbase
+
+import (
+	"fmt"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFaultInjector(t *testing.T) {
+	mockServer := &MockHbase{}
+	mockServer.On("IsTableEnabled", Bytes("table")).Return(true, nil)
+	mockServer.On("GetRow", Text("table"), Text("row"), mock.Anything).
+		Return([]*TRowResult_{{Row: Text("row")}}, nil)
+	mockServer.On("GetTableNames").Return([][]byte{[]byte("table")}, nil)
+
+	faults := NewFaultInjector(
+		FaultRule{Method: "GetRow", Table: "table", Err: &IOError{Message: "injected"}},
+		FaultRule{Method: "IsTableEnabled", Err: &IllegalArgument{Message: "injected"}},
+	)
+	srv, err := NewHbaseServer(Intercept(mockServer, faults.Interceptor()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	rawConn, err := ThriftClientFactory(fmt.Sprintf("127.0.0.1:%d", srv.Port))()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rawConn.Close()
+	hConn := NewConn(rawConn)
+
+	if _, err := hConn.GetRow(Text("table"), Text("row"), nil); ErrorClass(err) != ErrClassIO {
+		t.Fatalf("expected io error, got %v", err)
+	}
+	// IsTableEnabled does not declare IllegalArgument
+	if _, err := hConn.IsTableEnabled(Bytes("table")); ErrorClass(err) != ErrClassApplication {
+		t.Fatalf("expected application error, got %v", err)
+	}
+
+	faults.SetRules()
+	if _, err := hConn.GetRow(Text("table"), Text("row"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	faults.SetRules(
+		FaultRule{Method: "GetRow", Probability: Chance(0), Err: &IOError{Message: "never"}},
+		FaultRule{Method: "GetRow", Probability: Chance(0.5), Err: &IOError{Message: "half"}},
+	)
+	faults.Seed(1)
+	fired := 0
+	for i := 0; i < 1000; i++ {
+		if r := faults.match("GetRow", Text("table")); r != nil {
+			if r.Err.(*IOError).Message != "half" {
+				t.Fatalf("unexpected rule %v fired", r.Err)
+			}
+			fired++
+		}
+	}
+	if fired < 400 || fired > 600 {
+		t.Fatalf("rule of probability 0.5 fired %d times out of 1000", fired)
+	}
+}
+
+func TestFaultProxy(t *testing.T) {
+	mockServer := &MockHbase{}
+	mockServer.On("IsTableEnabled", Bytes("table")).Return(true, nil)
+	mockServer.On("GetTableNames").Return([][]byte{[]byte("table")}, nil)
+
+	srv, err := NewHbaseServer(mockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	faults := NewFaultInjector(
+		FaultRule{Method: "GetRow", Table: "table", Err: &IOError{Message: "injected"}},
+		FaultRule{Method: "DeleteTable", Err: thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "injected")},
+		FaultRule{Method: "GetTableNames", Truncate: true},
+	)
+	proxy, err := NewFaultProxy(fmt.Sprintf("127.0.0.1:%d", srv.Port), faults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+
+	connect := func() *WrapConn {
+		rawConn, err := ThriftClientFactory(proxy.Addr)()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewConn(rawConn)
+	}
+
+	hConn := connect()
+	if ok, err := hConn.IsTableEnabled(Bytes("table")); err != nil || !ok {
+		t.Fatalf("unexpected result: %v, %v", ok, err)
+	}
+	if _, err := hConn.GetRow(Text("table"), Text("row"), nil); ErrorClass(err) != ErrClassIO {
+		t.Fatalf("expected io error, got %v", err)
+	}
+	if err := hConn.DeleteTable(Text("table")); ErrorClass(err) != ErrClassApplication {
+		t.Fatalf("expected application error, got %v", err)
+	}
+	// the connection is still usable after injected errors
+	if ok, err := hConn.IsTableEnabled(Bytes("table")); err != nil || !ok {
+		t.Fatalf("unexpected result: %v, %v", ok, err)
+	}
+	if _, err := hConn.GetTableNames(); err == nil {
+		t.Fatal("expected error on truncated frame")
+	}
+
+	faults.SetRules(FaultRule{Method: "IsTableEnabled", Reset: true, ResetAfter: 2})
+	hConn = connect()
+	if _, err := hConn.IsTableEnabled(Bytes("table")); err == nil {
+		t.Fatal("expected error on reset connection")
+	}
+}
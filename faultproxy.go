@@ -0,0 +1,306 @@
+package hbase
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// FaultProxy is a TCP proxy which injects faults into the framed thrift
+// traffic between clients, e.g. from ThriftClientFactory, and a server.
+// Calls are matched against the rules of a FaultInjector by method and
+// table, which the proxy decodes from the request frames.
+type FaultProxy struct {
+	// Addr is the address clients should connect to
+	Addr string
+
+	target   string
+	faults   *FaultInjector
+	listener net.Listener
+
+	// mu guards conns
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewFaultProxy starts a proxy on a free local port forwarding to target.
+func NewFaultProxy(target string, faults *FaultInjector) (*FaultProxy, error) {
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &FaultProxy{
+		Addr:     l.Addr().String(),
+		target:   target,
+		faults:   faults,
+		listener: l,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	p.wg.Add(1)
+	go p.acceptLoop()
+	return p, nil
+}
+
+// Close stops the proxy and closes all proxied connections.
+func (p *FaultProxy) Close() error {
+	err := p.listener.Close()
+	p.mu.Lock()
+	for c := range p.conns {
+		c.Close()
+	}
+	p.conns = nil
+	p.mu.Unlock()
+	p.wg.Wait()
+	return err
+}
+
+func (p *FaultProxy) acceptLoop() {
+	defer p.wg.Done()
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		server, err := net.DialTimeout("tcp", p.target, defaultTimeout)
+		if err != nil {
+			client.Close()
+			continue
+		}
+		if !p.track(client, server) {
+			return
+		}
+		pc := &proxyConn{
+			proxy:   p,
+			client:  client,
+			server:  server,
+			pending: make(chan *FaultRule, 64),
+		}
+		p.wg.Add(2)
+		go pc.forwardRequests()
+		go pc.forwardResponses()
+	}
+}
+
+// track registers conns to be closed by Close. It returns false if the
+// proxy is already closed.
+func (p *FaultProxy) track(conns ...net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conns == nil {
+		for _, c := range conns {
+			c.Close()
+		}
+		return false
+	}
+	for _, c := range conns {
+		p.conns[c] = struct{}{}
+	}
+	return true
+}
+
+// proxyConn is a proxied client connection
+type proxyConn struct {
+	proxy          *FaultProxy
+	client, server net.Conn
+
+	// pending holds the rule, or nil, of every request forwarded to the
+	// server, in order, to be applied to its response
+	pending chan *FaultRule
+	// writeMu serializes writes to the client
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+// close closes both sides of the connection. With reset, the client side
+// is closed with a TCP reset instead of a regular close.
+func (c *proxyConn) close(reset bool) {
+	c.closeOnce.Do(func() {
+		if tcp, ok := c.client.(*net.TCPConn); ok && reset {
+			tcp.SetLinger(0)
+		}
+		c.client.Close()
+		c.server.Close()
+		c.proxy.mu.Lock()
+		delete(c.proxy.conns, c.client)
+		delete(c.proxy.conns, c.server)
+		c.proxy.mu.Unlock()
+	})
+}
+
+// forwardRequests reads request frames from the client, applies the rules
+// and forwards them to the server.
+func (c *proxyConn) forwardRequests() {
+	defer c.proxy.wg.Done()
+	defer close(c.pending)
+	for {
+		frame, err := readFrame(c.client)
+		if err != nil {
+			c.close(false)
+			return
+		}
+		name, seqID, args, err := decodeRequest(frame[4:])
+		var r *FaultRule
+		if err == nil {
+			r = c.proxy.faults.match(thriftToMethod(name), TableOf(args))
+		}
+		if r != nil && r.Latency > 0 {
+			time.Sleep(r.Latency)
+		}
+		if r != nil && r.Err != nil {
+			reply, err := encodeFault(name, seqID, r.Err)
+			if err == nil {
+				c.writeMu.Lock()
+				_, err = c.client.Write(reply)
+				c.writeMu.Unlock()
+			}
+			if err != nil {
+				c.close(false)
+				return
+			}
+			continue
+		}
+		c.pending <- r
+		if _, err := c.server.Write(frame); err != nil {
+			c.close(false)
+			return
+		}
+	}
+}
+
+// forwardResponses reads response frames from the server and forwards
+// them to the client, resetting or truncating them as the rule of their
+// request says.
+func (c *proxyConn) forwardResponses() {
+	defer c.proxy.wg.Done()
+	for {
+		frame, err := readFrame(c.server)
+		if err != nil {
+			c.close(false)
+			return
+		}
+		r := <-c.pending
+		switch {
+		case r != nil && r.Reset:
+			if r.ResetAfter < len(frame) {
+				frame = frame[:r.ResetAfter]
+			}
+		case r != nil && r.Truncate:
+			frame = frame[:4+(len(frame)-4)/2]
+		}
+		c.writeMu.Lock()
+		_, err = c.client.Write(frame)
+		c.writeMu.Unlock()
+		if err != nil || (r != nil && (r.Reset || r.Truncate)) {
+			c.close(r != nil && r.Reset)
+			return
+		}
+	}
+}
+
+// readFrame reads a frame of the thrift framed transport, including its
+// 4 bytes length header.
+func readFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header)
+	if size > thrift.DEFAULT_MAX_LENGTH {
+		return nil, fmt.Errorf("frame too large: %d", size)
+	}
+	frame := make([]byte, 4+size)
+	copy(frame, header)
+	if _, err := io.ReadFull(r, frame[4:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// decodeRequest decodes the name, sequence id and arguments of a request
+// encoded with the binary protocol.
+func decodeRequest(payload []byte) (name string, seqID int32, args interface{}, err error) {
+	buf := thrift.NewTMemoryBuffer()
+	buf.Write(payload)
+	iprot := thrift.NewTBinaryProtocolTransport(buf)
+	name, _, seqID, err = iprot.ReadMessageBegin()
+	if err != nil {
+		return "", 0, nil, err
+	}
+	args, _, ok := NewCall(thriftToMethod(name))
+	if !ok {
+		return name, seqID, nil, fmt.Errorf("unknown method %s", name)
+	}
+	if err := args.(thrift.TStruct).Read(iprot); err != nil {
+		return name, seqID, nil, err
+	}
+	return name, seqID, args, nil
+}
+
+// encodeFault encodes a framed reply to the request name which fails with
+// err. IOError and IllegalArgument are sent as declared exceptions of the
+// method when possible, other errors as TApplicationException.
+func encodeFault(name string, seqID int32, err error) ([]byte, error) {
+	buf := thrift.NewTMemoryBuffer()
+	oprot := thrift.NewTBinaryProtocolTransport(buf)
+
+	var reply thrift.TStruct
+	if _, result, ok := NewCall(thriftToMethod(name)); ok {
+		field := ""
+		switch err.(type) {
+		case *IOError:
+			field = "Io"
+		case *IllegalArgument:
+			field = "Ia"
+		}
+		if f := argsField(result, field); field != "" && f.IsValid() {
+			f.Set(reflect.ValueOf(err))
+			reply = result.(thrift.TStruct)
+		}
+	}
+	if reply != nil {
+		if err := oprot.WriteMessageBegin(name, thrift.REPLY, seqID); err != nil {
+			return nil, err
+		}
+		if err := reply.Write(oprot); err != nil {
+			return nil, err
+		}
+	} else {
+		appErr, ok := err.(thrift.TApplicationException)
+		if !ok {
+			appErr = thrift.NewTApplicationException(thrift.INTERNAL_ERROR, err.Error())
+		}
+		if err := oprot.WriteMessageBegin(name, thrift.EXCEPTION, seqID); err != nil {
+			return nil, err
+		}
+		if err := appErr.Write(oprot); err != nil {
+			return nil, err
+		}
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return nil, err
+	}
+	if err := oprot.Flush(); err != nil {
+		return nil, err
+	}
+
+	frame := make([]byte, 4, 4+buf.Len())
+	binary.BigEndian.PutUint32(frame, uint32(buf.Len()))
+	return append(frame, buf.Bytes()...), nil
+}
+
+// thriftToMethod converts a thrift method name, e.g. getRow, to the name of
+// the Hbase method, e.g. GetRow.
+func thriftToMethod(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
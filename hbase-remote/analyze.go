@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/csigo/hbase"
+)
+
+// runAnalyze implements the analyze command, which reports the key
+// distribution of a table, sampled by a scan or from recorded calls:
+//
+//	hbase-remote -h host:port -framed analyze [flags] table
+func runAnalyze(client hbase.Hbase, args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	records := fs.String("records", "", "file of calls written by a Recorder to analyze instead of scanning the table")
+	binary := fs.Bool("binary", false, "the records file is in the binary record format")
+	every := fs.Int("every", 1, "sample every nth row of the table")
+	prefixLen := fs.Int("prefix-len", 1, "length in bytes of the key prefixes counted")
+	top := fs.Int("top", 20, "number of most frequent prefixes reported")
+	splits := fs.Int("splits", 0, "number of regions to suggest split points for, defaults to twice the current regions")
+	asJSON := fs.Bool("json", false, "write the report as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("analyze requires a table name")
+	}
+	table := hbase.Text(fs.Arg(0))
+
+	a := hbase.NewKeyAnalyzer(hbase.AnalyzeOptions{
+		PrefixLen:   *prefixLen,
+		TopPrefixes: *top,
+		Splits:      *splits,
+	})
+	if *records != "" {
+		f, err := os.Open(*records)
+		if err != nil {
+			return err
+		}
+		format := hbase.RecordJSON
+		if *binary {
+			format = hbase.RecordBinary
+		}
+		calls, err := hbase.ReadRecords(f, format)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		a.AddRecords(calls, table)
+	} else if err := a.SampleTable(client, table, *every); err != nil {
+		return err
+	}
+	regions, err := client.GetTableRegions(table)
+	if err != nil {
+		return err
+	}
+
+	report := a.Report(regions)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return report.WriteText(os.Stdout)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/csigo/hbase"
+)
+
+// parseTimestamp parses a timestamp in milliseconds or in RFC 3339 format.
+func parseTimestamp(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q, expected milliseconds or RFC 3339", s)
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
+
+// runBackup implements the backup command, which takes a full or
+// incremental backup of a table into a directory:
+//
+//	hbase-remote -h host:port -framed backup [flags] table dir
+func runBackup(client hbase.Hbase, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	incremental := fs.Bool("incremental", false, "back up the cells written since the last backup")
+	until := fs.String("until", "", "latest cell timestamp to back up, in milliseconds or RFC 3339, defaults to now")
+	maxRows := fs.Int("max-rows", 0, "start a new backup file after this many rows")
+	batch := fs.Int("batch", 100, "rows fetched per round trip")
+	compress := fs.Bool("gzip", false, "gzip the backup files")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("backup requires a table name and a directory")
+	}
+	ts, err := parseTimestamp(*until)
+	if err != nil {
+		return err
+	}
+	info, err := hbase.Backup(client, hbase.Text(fs.Arg(0)), fs.Arg(1), hbase.BackupOptions{
+		Incremental: *incremental,
+		Until:       ts,
+		Compress:    *compress,
+		MaxRows:     *maxRows,
+		BatchSize:   int32(*batch),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "backup %s: %d rows, %d cells in (%d, %d]\n", info.ID, info.Rows, info.Cells, info.Since, info.Until)
+	return nil
+}
+
+// runRestore implements the restore command, which restores the backups of
+// a directory into a table:
+//
+//	hbase-remote -h host:port -framed restore [flags] table dir
+func runRestore(client hbase.Hbase, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	at := fs.String("at", "", "point in time to restore, in milliseconds or RFC 3339, defaults to the latest backup")
+	start := fs.String("start", "", "first row to restore")
+	stop := fs.String("stop", "", "row to stop the restore before")
+	batch := fs.Int("batch", 100, "rows written per round trip")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("restore requires a table name and a directory")
+	}
+	ts, err := parseTimestamp(*at)
+	if err != nil {
+		return err
+	}
+	stats, err := hbase.Restore(client, hbase.Text(fs.Arg(0)), fs.Arg(1), hbase.RestoreOptions{
+		PointInTime: ts,
+		StartRow:    hbase.Text(*start),
+		StopRow:     hbase.Text(*stop),
+		BatchSize:   *batch,
+	})
+	fmt.Fprintf(os.Stderr, "restored %d rows, %d cells\n", stats.Rows, stats.Cells)
+	return err
+}
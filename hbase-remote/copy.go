@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/csigo/hbase"
+)
+
+// runCopy implements the copy command, which copies a table to the same or
+// another gateway over pools of framed connections:
+//
+//	hbase-remote -h host:port copy [flags] table [dest-table]
+func runCopy(addr string, args []string) error {
+	fs := flag.NewFlagSet("copy", flag.ExitOnError)
+	dest := fs.String("dest", "", "destination gateway host:port, defaults to the source gateway")
+	start := fs.String("start", "", "first row to copy")
+	stop := fs.String("stop", "", "row to stop the copy before")
+	columns := fs.String("columns", "", "comma separated families or family:qualifier columns to copy")
+	rename := fs.String("rename", "", "comma separated source=destination family renames")
+	workers := fs.Int("workers", 4, "regions copied concurrently")
+	batch := fs.Int("batch", 100, "rows fetched and written per round trip")
+	quiet := fs.Bool("quiet", false, "do not report progress")
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("copy requires a table name and an optional destination table")
+	}
+	if addr == "" {
+		return fmt.Errorf("copy requires a socket transport")
+	}
+	if *dest == "" {
+		*dest = addr
+	}
+	families := map[string]string{}
+	if *rename != "" {
+		for _, pair := range strings.Split(*rename, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid family rename %q, expected source=destination", pair)
+			}
+			families[kv[0]] = kv[1]
+		}
+	}
+	srcTable := hbase.Text(fs.Arg(0))
+	dstTable := srcTable
+	if fs.NArg() == 2 {
+		dstTable = hbase.Text(fs.Arg(1))
+	}
+
+	src, err := hbase.NewMux(hbase.ThriftClientFactory(addr), *workers)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := hbase.NewMux(hbase.ThriftClientFactory(*dest), *workers)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	opts := hbase.CopyOptions{
+		StartRow:  hbase.Text(*start),
+		StopRow:   hbase.Text(*stop),
+		Columns:   splitColumns(*columns),
+		Families:  families,
+		Workers:   *workers,
+		BatchSize: *batch,
+	}
+	if !*quiet {
+		opts.Progress = func(p hbase.CopyProgress) {
+			fmt.Fprintf(os.Stderr, "\rregions %d/%d, %d rows, %d cells", p.RegionsDone, p.Regions, p.Rows, p.Cells)
+		}
+	}
+	progress, err := hbase.Copy(src, srcTable, dst, dstTable, opts)
+	if !*quiet {
+		fmt.Fprintln(os.Stderr)
+	}
+	fmt.Fprintf(os.Stderr, "copied %d rows, %d cells\n", progress.Rows, progress.Cells)
+	return err
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/csigo/hbase"
+)
+
+// splitColumns splits a comma separated list of families or
+// family:qualifier columns.
+func splitColumns(s string) [][]byte {
+	if s == "" {
+		return nil
+	}
+	var columns [][]byte
+	for _, c := range strings.Split(s, ",") {
+		columns = append(columns, []byte(c))
+	}
+	return columns
+}
+
+// runExport implements the export command, which dumps the rows of a table
+// to stdout or to numbered files:
+//
+//	hbase-remote -h host:port -framed export [flags] table
+func runExport(client hbase.Hbase, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	start := fs.String("start", "", "first row to export")
+	stop := fs.String("stop", "", "row to stop the export before")
+	prefix := fs.String("prefix", "", "export only rows with this prefix")
+	columns := fs.String("columns", "", "comma separated families or family:qualifier columns to export")
+	filter := fs.String("filter", "", "HBase filter string, e.g. \"KeyOnlyFilter()\"")
+	timestamp := fs.Int64("timestamp", 0, "export only cells written before this timestamp")
+	limit := fs.Int("limit", 0, "maximum number of rows to export")
+	batch := fs.Int("batch", 100, "rows fetched per round trip")
+	format := fs.String("format", "json", "output format: json, csv or binary")
+	encoding := fs.String("encoding", "base64", "encoding of keys and values in json and csv: base64, hex or utf8")
+	out := fs.String("out", "", "prefix of output files, stdout if empty")
+	maxRows := fs.Int("max-rows", 0, "start a new output file after this many rows")
+	maxBytes := fs.Int64("max-bytes", 0, "start a new output file after this many uncompressed bytes")
+	compress := fs.Bool("gzip", false, "gzip the output")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("export requires a table name")
+	}
+
+	exportFormat, err := hbase.ParseExportFormat(*format)
+	if err != nil {
+		return err
+	}
+	enc, err := hbase.ParseValueEncoding(*encoding)
+	if err != nil {
+		return err
+	}
+	opts := hbase.ExportOptions{
+		StartRow:     hbase.Text(*start),
+		StopRow:      hbase.Text(*stop),
+		Prefix:       hbase.Text(*prefix),
+		Columns:      splitColumns(*columns),
+		FilterString: hbase.Text(*filter),
+		Timestamp:    *timestamp,
+		Limit:        *limit,
+		BatchSize:    int32(*batch),
+	}
+
+	var w hbase.RowWriter
+	var gz *gzip.Writer
+	if *out != "" {
+		w = hbase.NewSplitWriter(*out, exportFormat, enc, *compress, *maxRows, *maxBytes)
+	} else {
+		var stdout io.Writer = os.Stdout
+		if *compress {
+			gz = gzip.NewWriter(os.Stdout)
+			stdout = gz
+		}
+		w = hbase.NewRowWriter(stdout, exportFormat, enc)
+	}
+
+	n, err := hbase.Export(client, hbase.Text(fs.Arg(0)), opts, w)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if gz != nil {
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	fmt.Fprintf(os.Stderr, "exported %d rows\n", n)
+	return err
+}
@@ -64,6 +64,15 @@ func Usage() {
 	fmt.Fprintln(os.Stderr, "  TRegionInfo getRegionInfo(Text row)")
 	fmt.Fprintln(os.Stderr, "   append(TAppend append)")
 	fmt.Fprintln(os.Stderr, "  bool checkAndPut(Text tableName, Text row, Text column, Text value, Mutation mput,  attributes)")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	fmt.Fprintln(os.Stderr, "  export [flags] table")
+	fmt.Fprintln(os.Stderr, "  import [flags] table file...")
+	fmt.Fprintln(os.Stderr, "  backup [flags] table dir")
+	fmt.Fprintln(os.Stderr, "  restore [flags] table dir")
+	fmt.Fprintln(os.Stderr, "  copy [flags] table [dest-table]")
+	fmt.Fprintln(os.Stderr, "  verify [flags] table [target-table]")
+	fmt.Fprintln(os.Stderr, "  stats [flags] table")
+	fmt.Fprintln(os.Stderr, "  analyze [flags] table")
 	fmt.Fprintln(os.Stderr)
 	os.Exit(0)
 }
@@ -78,6 +87,8 @@ func main() {
 	var useHttp bool
 	var parsedUrl url.URL
 	var trans thrift.TTransport
+	// addr is the gateway address of socket transports
+	var addr string
 	_ = strconv.Atoi
 	_ = math.Abs
 	flag.Usage = Usage
@@ -118,7 +129,8 @@ func main() {
 				os.Exit(1)
 			}
 		}
-		trans, err = thrift.NewTSocket(net.JoinHostPort(host, portStr))
+		addr = net.JoinHostPort(host, portStr)
+		trans, err = thrift.NewTSocket(addr)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "error resolving address:", err)
 			os.Exit(1)
@@ -1636,6 +1648,54 @@ func main() {
 		fmt.Print(client.CheckAndPut(value0, value1, value2, value3, value4, value5))
 		fmt.Print("\n")
 		break
+	case "export":
+		if err := runExport(client, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "export failed:", err)
+			os.Exit(1)
+		}
+		break
+	case "import":
+		if err := runImport(client, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "import failed:", err)
+			os.Exit(1)
+		}
+		break
+	case "backup":
+		if err := runBackup(client, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "backup failed:", err)
+			os.Exit(1)
+		}
+		break
+	case "restore":
+		if err := runRestore(client, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "restore failed:", err)
+			os.Exit(1)
+		}
+		break
+	case "copy":
+		if err := runCopy(addr, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "copy failed:", err)
+			os.Exit(1)
+		}
+		break
+	case "verify":
+		if err := runVerify(addr, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "verify failed:", err)
+			os.Exit(1)
+		}
+		break
+	case "stats":
+		if err := runStats(client, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "stats failed:", err)
+			os.Exit(1)
+		}
+		break
+	case "analyze":
+		if err := runAnalyze(client, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "analyze failed:", err)
+			os.Exit(1)
+		}
+		break
 	case "":
 		Usage()
 		break
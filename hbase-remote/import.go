@@ -0,0 +1,131 @@
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/csigo/hbase"
+)
+
+// parseColumnMap parses a comma separated list of header=family:qualifier
+// pairs.
+func parseColumnMap(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	m := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid column mapping %q, expected header=family:qualifier", pair)
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m, nil
+}
+
+// openInput opens an import file, "-" is stdin. Files ending in .gz are
+// decompressed.
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return os.Stdin, nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, f}, nil
+}
+
+// runImport implements the import command, which loads rows from files
+// written by export or from CSV files:
+//
+//	hbase-remote -h host:port -framed import [flags] table file...
+func runImport(client hbase.Hbase, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "json", "input format: json, csv or binary")
+	encoding := fs.String("encoding", "base64", "encoding of keys and values of exported json and csv: base64, hex or utf8")
+	columns := fs.String("map", "", "comma separated header=family:qualifier mapping of csv fields")
+	key := fs.String("key", "{row}", "row key template of mapped csv records, e.g. \"{user}#{day}\"")
+	tsField := fs.String("timestamp-field", "", "csv header of cell timestamps in milliseconds")
+	timestamps := fs.Bool("timestamps", false, "keep the cell timestamps of exported files")
+	batch := fs.Int("batch", 100, "rows written per round trip")
+	concurrency := fs.Int("concurrency", 1, "concurrent writes")
+	rate := fs.Float64("rate", 0, "maximum rows written per second")
+	checkpoint := fs.String("checkpoint", "", "checkpoint file to resume an import from")
+	dryRun := fs.Bool("dry-run", false, "only validate the input")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		return fmt.Errorf("import requires a table name and input files")
+	}
+
+	importFormat, err := hbase.ParseExportFormat(*format)
+	if err != nil {
+		return err
+	}
+	enc, err := hbase.ParseValueEncoding(*encoding)
+	if err != nil {
+		return err
+	}
+	columnMap, err := parseColumnMap(*columns)
+	if err != nil {
+		return err
+	}
+	opts := hbase.ImportOptions{
+		Format:         importFormat,
+		Encoding:       enc,
+		ColumnMap:      columnMap,
+		RowKey:         *key,
+		TimestampField: *tsField,
+		Timestamps:     *timestamps,
+		BatchSize:      *batch,
+		Concurrency:    *concurrency,
+		RateLimit:      *rate,
+		DryRun:         *dryRun,
+	}
+
+	table := hbase.Text(fs.Arg(0))
+	var total hbase.ImportStats
+	for i, name := range fs.Args()[1:] {
+		if *checkpoint != "" {
+			opts.Checkpoint = *checkpoint
+			if fs.NArg() > 2 {
+				opts.Checkpoint = fmt.Sprintf("%s-%d", *checkpoint, i)
+			}
+		}
+		r, err := openInput(name)
+		if err != nil {
+			return err
+		}
+		stats, err := hbase.Import(client, table, r, opts)
+		r.Close()
+		total.Records += stats.Records
+		total.Skipped += stats.Skipped
+		total.Rows += stats.Rows
+		total.Cells += stats.Cells
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	verb := "imported"
+	if *dryRun {
+		verb = "validated"
+	}
+	fmt.Fprintf(os.Stderr, "%s %d rows, %d cells, skipped %d records\n", verb, total.Rows, total.Cells, total.Skipped)
+	return nil
+}
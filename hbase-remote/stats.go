@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/csigo/hbase"
+)
+
+// runStats implements the stats command, which counts the rows of a table
+// and optionally reports statistics of its families:
+//
+//	hbase-remote -h host:port -framed stats [flags] table
+func runStats(client hbase.Hbase, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	start := fs.String("start", "", "first row to count")
+	stop := fs.String("stop", "", "row to stop counting before")
+	columns := fs.String("columns", "", "comma separated families or family:qualifier columns to count")
+	full := fs.Bool("full", false, "scan all cells to report family statistics")
+	versions := fs.Int("versions", 0, "in full mode, count up to this many versions of every cell")
+	batch := fs.Int("batch", 1000, "rows fetched per round trip")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("stats requires a table name")
+	}
+
+	stats, err := hbase.TableStatistics(client, hbase.Text(fs.Arg(0)), hbase.StatsOptions{
+		StartRow:    hbase.Text(*start),
+		StopRow:     hbase.Text(*stop),
+		Columns:     splitColumns(*columns),
+		Full:        *full,
+		MaxVersions: int32(*versions),
+		BatchSize:   int32(*batch),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("rows: %d\n", stats.Rows)
+	for _, r := range stats.Regions {
+		fmt.Printf("region [%s, %s): %d rows\n", hbase.ToStringBinary(r.StartKey), hbase.ToStringBinary(r.EndKey), r.Rows)
+	}
+	families := make([]string, 0, len(stats.Families))
+	for name := range stats.Families {
+		families = append(families, name)
+	}
+	sort.Strings(families)
+	for _, name := range families {
+		f := stats.Families[name]
+		fmt.Printf("family %s: %d cells, %d value bytes, value size avg %.1f p50 %d p90 %d p99 %d max %d",
+			name, f.Cells, f.ValueBytes, f.ValueSizes.Avg, f.ValueSizes.P50, f.ValueSizes.P90, f.ValueSizes.P99, f.ValueSizes.Max)
+		if *versions > 0 {
+			fmt.Printf(", %d versions", f.Versions)
+		}
+		fmt.Println()
+	}
+	return nil
+}
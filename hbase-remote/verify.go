@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/csigo/hbase"
+)
+
+// runVerify implements the verify command, which compares a table with a
+// table of the same or another gateway:
+//
+//	hbase-remote -h host:port verify [flags] table [target-table]
+func runVerify(addr string, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	targetAddr := fs.String("target", "", "target gateway host:port, defaults to the source gateway")
+	start := fs.String("start", "", "first row to verify")
+	stop := fs.String("stop", "", "row to stop the verification before")
+	columns := fs.String("columns", "", "comma separated families or family:qualifier columns to verify")
+	workers := fs.Int("workers", 4, "regions verified concurrently")
+	batch := fs.Int("batch", 100, "rows fetched per round trip")
+	sample := fs.Int("sample", 10, "number of mismatches to print")
+	checksum := fs.Bool("checksum", false, "only compare checksums of region ranges")
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("verify requires a table name and an optional target table")
+	}
+	if addr == "" {
+		return fmt.Errorf("verify requires a socket transport")
+	}
+	if *targetAddr == "" {
+		*targetAddr = addr
+	}
+	sourceTable := hbase.Text(fs.Arg(0))
+	targetTable := sourceTable
+	if fs.NArg() == 2 {
+		targetTable = hbase.Text(fs.Arg(1))
+	}
+
+	source, err := hbase.NewMux(hbase.ThriftClientFactory(addr), *workers)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := hbase.NewMux(hbase.ThriftClientFactory(*targetAddr), *workers)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	report, err := hbase.Verify(source, sourceTable, target, targetTable, hbase.VerifyOptions{
+		StartRow:   hbase.Text(*start),
+		StopRow:    hbase.Text(*stop),
+		Columns:    splitColumns(*columns),
+		Workers:    *workers,
+		BatchSize:  int32(*batch),
+		SampleSize: *sample,
+		Checksum:   *checksum,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d rows compared, %d matched\n", report.Rows, report.Matched)
+	for kind := hbase.MissingRow; kind <= hbase.TimestampMismatch; kind++ {
+		if n := report.Counts[kind]; n > 0 {
+			fmt.Printf("%v: %d\n", kind, n)
+		}
+	}
+	for _, m := range report.Samples {
+		fmt.Println(m)
+	}
+	for _, r := range report.Ranges {
+		fmt.Printf("range [%s, %s) differs: %d source rows, %d target rows\n", hbase.ToStringBinary(r.StartRow), hbase.ToStringBinary(r.StopRow), r.SourceRows, r.TargetRows)
+	}
+	if !report.OK() {
+		return fmt.Errorf("tables differ")
+	}
+	return nil
+}
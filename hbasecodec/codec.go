@@ -0,0 +1,53 @@
+// Package hbasecodec provides Protobuf and MessagePack hbase value codecs
+// and snappy and zstd value compressors. Importing it registers the codecs
+// as "protobuf" and "msgpack" and the compressors for use by
+// hbase.CompressionInterceptor.
+package hbasecodec
+
+import (
+	"fmt"
+
+	"github.com/csigo/hbase"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// Protobuf stores proto.Message values in the protobuf wire format
+	Protobuf hbase.Codec = protobufCodec{}
+	// MessagePack stores values as MessagePack
+	MessagePack hbase.Codec = msgpackCodec{}
+)
+
+func init() {
+	hbase.RegisterCodec("protobuf", Protobuf)
+	hbase.RegisterCodec("msgpack", MessagePack)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Encode(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %T as protobuf, not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Decode(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("cannot decode protobuf into %T, not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Encode(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Decode(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,39 @@
+package hbasecodec
+
+import (
+	"testing"
+
+	"github.com/csigo/hbase"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodecs(t *testing.T) {
+	b, err := Protobuf.Encode(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s wrapperspb.StringValue
+	if err := Protobuf.Decode(b, &s); err != nil || s.Value != "hello" {
+		t.Fatalf("unexpected message %v: %v", &s, err)
+	}
+	if _, err := Protobuf.Encode("hello"); err == nil {
+		t.Fatal("expected an error encoding a string as protobuf")
+	}
+
+	type event struct {
+		Name  string
+		Count int
+	}
+	b, err = MessagePack.Encode(event{Name: "click", Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e event
+	if err := MessagePack.Decode(b, &e); err != nil || e != (event{Name: "click", Count: 3}) {
+		t.Fatalf("unexpected event %+v: %v", e, err)
+	}
+
+	if c, ok := hbase.LookupCodec("msgpack"); !ok || c != MessagePack {
+		t.Fatal("msgpack codec is not registered")
+	}
+}
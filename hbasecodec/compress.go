@@ -0,0 +1,58 @@
+package hbasecodec
+
+import (
+	"errors"
+
+	"github.com/csigo/hbase"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	hbase.RegisterCompressor(hbase.CompressSnappy, snappyCompressor{})
+	hbase.RegisterCompressor(hbase.CompressZstd, newZstdCompressor())
+}
+
+type snappyCompressor struct{}
+
+func (snappyCompressor) Compress(src []byte) ([]byte, error) {
+	return snappy.Encode(nil, src), nil
+}
+
+func (snappyCompressor) Decompress(src []byte) ([]byte, error) {
+	n, err := snappy.DecodedLen(src)
+	if err != nil {
+		return nil, err
+	}
+	if n > hbase.MaxDecompressedSize {
+		return nil, hbase.ErrDecompressedSize
+	}
+	return snappy.Decode(nil, src)
+}
+
+// zstdCompressor shares an encoder and a decoder, whose EncodeAll and
+// DecodeAll are safe for concurrent use
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor() *zstdCompressor {
+	// creating an encoder or decoder without a reader or writer only fails
+	// on invalid options
+	encoder, _ := zstd.NewWriter(nil)
+	decoder, _ := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(hbase.MaxDecompressedSize))
+	return &zstdCompressor{encoder: encoder, decoder: decoder}
+}
+
+func (c *zstdCompressor) Compress(src []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(src, nil), nil
+}
+
+func (c *zstdCompressor) Decompress(src []byte) ([]byte, error) {
+	b, err := c.decoder.DecodeAll(src, nil)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return nil, hbase.ErrDecompressedSize
+	}
+	return b, err
+}
@@ -0,0 +1,56 @@
+package hbasecodec
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/csigo/hbase"
+)
+
+func TestCompressors(t *testing.T) {
+	value := strings.Repeat("compressible ", 100)
+	for _, algorithm := range []hbase.Compression{hbase.CompressSnappy, hbase.CompressZstd} {
+		interceptor := hbase.CompressionInterceptor(hbase.CompressionRule{Algorithm: algorithm})
+		var stored []byte
+		store := func(ctx context.Context, method string, args, result interface{}) error {
+			stored = args.(*hbase.MutateRowArgs).Mutations[0].Value
+			return nil
+		}
+		args := &hbase.MutateRowArgs{
+			TableName: hbase.Text("table"),
+			Row:       hbase.Text("row"),
+			Mutations: []*hbase.Mutation{{Column: hbase.Text("cf:q"), Value: hbase.Text(value)}},
+		}
+		if err := interceptor(context.Background(), "MutateRow", args, hbase.NewMutateRowResult(), store); err != nil {
+			t.Fatal(err)
+		}
+		if len(stored) >= len(value) || stored[0] != 0xF8+byte(algorithm) {
+			t.Fatalf("%v: value not compressed: %d bytes", algorithm, len(stored))
+		}
+
+		load := func(ctx context.Context, method string, args, result interface{}) error {
+			result.(*hbase.GetResult).Success = []*hbase.TCell{{Value: stored}}
+			return nil
+		}
+		result := hbase.NewGetResult()
+		get := &hbase.GetArgs{TableName: hbase.Text("table"), Row: hbase.Text("row"), Column: hbase.Text("cf:q")}
+		if err := interceptor(context.Background(), "Get", get, result, load); err != nil {
+			t.Fatal(err)
+		}
+		if string(result.Success[0].Value) != value {
+			t.Fatalf("%v: unexpected decompressed value", algorithm)
+		}
+
+		// values decompressing past the limit are rejected
+		args.Mutations[0].Value = make([]byte, hbase.MaxDecompressedSize+1)
+		if err := interceptor(context.Background(), "MutateRow", args, hbase.NewMutateRowResult(), store); err != nil {
+			t.Fatal(err)
+		}
+		err := interceptor(context.Background(), "Get", get, hbase.NewGetResult(), load)
+		if !errors.Is(err, hbase.ErrDecompressedSize) {
+			t.Fatalf("%v: expected a size error, got %v", algorithm, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Package hbaseotel traces hbase calls with OpenTelemetry.
+//
+// Every call gets a client span carrying its method, table and row counts.
+// For methods which accept attributes, the W3C trace context of the span is
+// injected into the attributes map, so that it reaches the gateway along
+// with the HBase operation. Scanners additionally get a span which lives
+// from ScannerOpen* to ScannerClose and records every batch as an event.
+package hbaseotel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/csigo/hbase"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/csigo/hbase/hbaseotel"
+
+// Attribute keys set on spans
+const (
+	MethodKey     = attribute.Key("hbase.method")
+	TableKey      = attribute.Key("hbase.table")
+	RowsKey       = attribute.Key("hbase.rows")
+	ResultRowsKey = attribute.Key("hbase.result_rows")
+	ErrorClassKey = attribute.Key("hbase.error_class")
+	ScannerKey    = attribute.Key("hbase.scanner_id")
+)
+
+// Tracer creates spans for hbase calls.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	// mu guards scanners
+	mu       sync.Mutex
+	scanners map[hbase.ScannerID]trace.Span
+}
+
+// New creates a Tracer with spans from tp. The global TracerProvider is
+// used if tp is nil.
+func New(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+		scanners:   make(map[hbase.ScannerID]trace.Span),
+	}
+}
+
+// Interceptor returns an hbase.Interceptor which traces every call. Use
+// hbase.InterceptedHbase.WithContext to parent the spans.
+func (t *Tracer) Interceptor() hbase.Interceptor {
+	return func(ctx context.Context, method string, args, result interface{}, invoker hbase.Invoker) error {
+		opts := []trace.SpanStartOption{
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "hbase"),
+				MethodKey.String(method),
+				RowsKey.Int(hbase.RowCount(args)),
+			),
+		}
+		if table := hbase.TableOf(args); table != nil {
+			opts = append(opts, trace.WithAttributes(TableKey.String(string(table))))
+		}
+		if scanner := t.scanner(args); scanner != nil {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: scanner.SpanContext()}))
+		}
+		ctx, span := t.tracer.Start(ctx, "hbase."+method, opts...)
+		defer span.End()
+
+		t.inject(ctx, args)
+		err := invoker(ctx, method, args, result)
+		if rows, ok := hbase.ResultRows(result); ok {
+			span.SetAttributes(ResultRowsKey.Int(rows))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.SetAttributes(ErrorClassKey.String(hbase.ErrorClass(err)))
+		}
+		t.trackScanner(ctx, method, args, result, err)
+		return err
+	}
+}
+
+// inject adds the trace context of ctx to the attributes of args. The
+// attributes are copied so the map of the caller is left untouched.
+func (t *Tracer) inject(ctx context.Context, args interface{}) {
+	attributes, ok := hbase.AttributesOf(args)
+	if !ok {
+		return
+	}
+	carrier := make(attributesCarrier, len(attributes)+1)
+	for k, v := range attributes {
+		carrier[k] = v
+	}
+	t.propagator.Inject(ctx, carrier)
+	hbase.SetAttributes(args, carrier)
+}
+
+// scanner returns the scanner span a scanner call belongs to, if any.
+func (t *Tracer) scanner(args interface{}) trace.Span {
+	var id hbase.ScannerID
+	switch a := args.(type) {
+	case *hbase.ScannerGetArgs:
+		id = a.Id
+	case *hbase.ScannerGetListArgs:
+		id = a.Id
+	case *hbase.ScannerCloseArgs:
+		id = a.Id
+	default:
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.scanners[id]
+}
+
+// trackScanner maintains the scanner spans: they are started when a
+// scanner is opened, get an event per batch and end when it is closed.
+func (t *Tracer) trackScanner(ctx context.Context, method string, args, result interface{}, err error) {
+	switch a := args.(type) {
+	case *hbase.ScannerGetArgs, *hbase.ScannerGetListArgs:
+		if span := t.scanner(a); span != nil {
+			rows, _ := hbase.ResultRows(result)
+			span.AddEvent("batch", trace.WithAttributes(RowsKey.Int(rows)))
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+		return
+	case *hbase.ScannerCloseArgs:
+		t.mu.Lock()
+		span := t.scanners[a.Id]
+		delete(t.scanners, a.Id)
+		t.mu.Unlock()
+		if span != nil {
+			span.AddEvent("close")
+			span.End()
+		}
+		return
+	}
+
+	opened, ok := result.(interface {
+		GetSuccess() hbase.ScannerID
+	})
+	if !ok || err != nil {
+		return
+	}
+	id := opened.GetSuccess()
+	attrs := []attribute.KeyValue{
+		MethodKey.String(method),
+		ScannerKey.Int(int(id)),
+	}
+	if table := hbase.TableOf(args); table != nil {
+		attrs = append(attrs, TableKey.String(string(table)))
+	}
+	_, span := t.tracer.Start(ctx, "hbase.scanner", trace.WithAttributes(attrs...))
+	span.AddEvent("open")
+
+	t.mu.Lock()
+	t.scanners[id] = span
+	t.mu.Unlock()
+}
+
+// Extract returns ctx with the trace context found in attributes, e.g. to
+// parent server side spans in an Hbase implementation.
+func Extract(ctx context.Context, attributes map[string]hbase.Text) context.Context {
+	return propagation.TraceContext{}.Extract(ctx, attributesCarrier(attributes))
+}
+
+// attributesCarrier adapts hbase attributes to a TextMapCarrier
+type attributesCarrier map[string]hbase.Text
+
+// Get implements propagation.TextMapCarrier.
+func (c attributesCarrier) Get(key string) string {
+	return string(c[key])
+}
+
+// Set implements propagation.TextMapCarrier.
+func (c attributesCarrier) Set(key, value string) {
+	c[key] = hbase.Text(value)
+}
+
+// Keys implements propagation.TextMapCarrier.
+func (c attributesCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
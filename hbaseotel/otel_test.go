@@ -0,0 +1,70 @@
+package hbaseotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/csigo/hbase"
+	"github.com/stretchr/testify/mock"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	hasTraceparent := mock.MatchedBy(func(attributes map[string]hbase.Text) bool {
+		return len(attributes["traceparent"]) > 0 && string(attributes["user"]) == "x"
+	})
+	mockHbase := &hbase.MockHbase{}
+	mockHbase.On("GetRow", hbase.Text("table"), hbase.Text("row"), hasTraceparent).
+		Return([]*hbase.TRowResult_{{Row: hbase.Text("row")}}, nil)
+	mockHbase.On("ScannerOpenWithScan", hbase.Text("table"), mock.Anything, hasTraceparent).
+		Return(hbase.ScannerID(3), nil)
+	mockHbase.On("ScannerGetList", hbase.ScannerID(3), int32(2)).
+		Return([]*hbase.TRowResult_{{Row: hbase.Text("a")}, {Row: hbase.Text("b")}}, nil)
+	mockHbase.On("ScannerClose", hbase.ScannerID(3)).Return(nil)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	h := hbase.Intercept(mockHbase, New(tp).Interceptor()).WithContext(context.Background())
+
+	attributes := map[string]hbase.Text{"user": hbase.Text("x")}
+	if _, err := h.GetRow(hbase.Text("table"), hbase.Text("row"), attributes); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := attributes["traceparent"]; ok {
+		t.Fatal("attributes of the caller should not be modified")
+	}
+
+	id, err := h.ScannerOpenWithScan(hbase.Text("table"), &hbase.TScan{}, attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.ScannerGetList(id, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.ScannerClose(id); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var scanner sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+		if span.Name() == "hbase.scanner" {
+			scanner = span
+		}
+	}
+	if len(names) != 5 {
+		t.Fatalf("unexpected spans: %v", names)
+	}
+	if scanner == nil {
+		t.Fatalf("missing scanner span in %v", names)
+	}
+	var events []string
+	for _, e := range scanner.Events() {
+		events = append(events, e.Name)
+	}
+	if len(events) != 3 || events[0] != "open" || events[1] != "batch" || events[2] != "close" {
+		t.Fatalf("unexpected scanner events: %v", events)
+	}
+}
@@ -0,0 +1,90 @@
+// Package hbaseprom reports hbase client metrics to Prometheus.
+package hbaseprom
+
+import (
+	"time"
+
+	"github.com/csigo/hbase"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements hbase.Metrics with Prometheus collectors.
+type Metrics struct {
+	latency  *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+	rows     *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+}
+
+// New creates a Metrics whose collectors are named under namespace and
+// registered to reg.
+func New(reg prometheus.Registerer, namespace string) (*Metrics, error) {
+	m := &Metrics{
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "hbase",
+			Name:      "call_duration_seconds",
+			Help:      "Latency of hbase calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "hbase",
+			Name:      "call_errors_total",
+			Help:      "Failed hbase calls by error class.",
+		}, []string{"method", "class"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "hbase",
+			Name:      "payload_bytes_total",
+			Help:      "Estimated payload bytes of hbase calls.",
+		}, []string{"method", "direction"}),
+		rows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "hbase",
+			Name:      "rows_total",
+			Help:      "Rows returned by hbase calls.",
+		}, []string{"method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "hbase",
+			Name:      "calls_in_flight",
+			Help:      "Hbase calls in flight.",
+		}, []string{"method"}),
+	}
+	for _, c := range []prometheus.Collector{m.latency, m.errors, m.bytes, m.rows, m.inFlight} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ObserveLatency implements hbase.Metrics.
+func (m *Metrics) ObserveLatency(method string, d time.Duration) {
+	m.latency.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// IncError implements hbase.Metrics.
+func (m *Metrics) IncError(method, class string) {
+	m.errors.WithLabelValues(method, class).Inc()
+}
+
+// AddBytes implements hbase.Metrics.
+func (m *Metrics) AddBytes(method string, written, read int) {
+	m.bytes.WithLabelValues(method, "written").Add(float64(written))
+	m.bytes.WithLabelValues(method, "read").Add(float64(read))
+}
+
+// AddRows implements hbase.Metrics.
+func (m *Metrics) AddRows(method string, rows int) {
+	m.rows.WithLabelValues(method).Add(float64(rows))
+}
+
+// AddInFlight implements hbase.Metrics.
+func (m *Metrics) AddInFlight(method string, delta int) {
+	m.inFlight.WithLabelValues(method).Add(float64(delta))
+}
+
+var _ hbase.Metrics = (*Metrics)(nil)
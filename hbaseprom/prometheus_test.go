@@ -0,0 +1,68 @@
+package hbaseprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m, err := New(reg, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(reg, "app"); err == nil {
+		t.Fatal("expected a duplicate registration error")
+	}
+
+	m.ObserveLatency("GetRow", 3*time.Millisecond)
+	m.ObserveLatency("GetRow", 2*time.Second)
+	m.IncError("GetRow", "io")
+	m.IncError("GetRow", "io")
+	m.AddBytes("MutateRow", 100, 10)
+	m.AddRows("GetRow", 5)
+	m.AddInFlight("GetRow", 2)
+	m.AddInFlight("GetRow", -1)
+
+	if n := testutil.ToFloat64(m.errors.WithLabelValues("GetRow", "io")); n != 2 {
+		t.Fatalf("unexpected errors %v", n)
+	}
+	if n := testutil.ToFloat64(m.bytes.WithLabelValues("MutateRow", "written")); n != 100 {
+		t.Fatalf("unexpected written bytes %v", n)
+	}
+	if n := testutil.ToFloat64(m.bytes.WithLabelValues("MutateRow", "read")); n != 10 {
+		t.Fatalf("unexpected read bytes %v", n)
+	}
+	if n := testutil.ToFloat64(m.rows.WithLabelValues("GetRow")); n != 5 {
+		t.Fatalf("unexpected rows %v", n)
+	}
+	if n := testutil.ToFloat64(m.inFlight.WithLabelValues("GetRow")); n != 1 {
+		t.Fatalf("unexpected calls in flight %v", n)
+	}
+
+	expected := `
+# HELP app_hbase_call_duration_seconds Latency of hbase calls.
+# TYPE app_hbase_call_duration_seconds histogram
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="0.005"} 1
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="0.01"} 1
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="0.025"} 1
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="0.05"} 1
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="0.1"} 1
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="0.25"} 1
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="0.5"} 1
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="1"} 1
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="2.5"} 2
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="5"} 2
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="10"} 2
+app_hbase_call_duration_seconds_bucket{method="GetRow",le="+Inf"} 2
+app_hbase_call_duration_seconds_sum{method="GetRow"} 2.003
+app_hbase_call_duration_seconds_count{method="GetRow"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "app_hbase_call_duration_seconds"); err != nil {
+		t.Fatal(err)
+	}
+}
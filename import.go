@@ -0,0 +1,587 @@
+package hbase
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	// Format is the format of the input, as written by Export
+	Format ExportFormat
+	// Encoding is the encoding of keys, columns and values of JSON input and
+	// of CSV input in the export layout
+	Encoding ValueEncoding
+
+	// ColumnMap maps CSV headers to family:qualifier columns. When set, each
+	// CSV line is a record whose mapped fields are written as plain values.
+	// Otherwise CSV input is expected in the row,column,timestamp,value
+	// layout of Export.
+	ColumnMap map[string]string
+	// RowKey is the row key template of mapped CSV records. Fields are
+	// referenced by header in braces, e.g. "{user}#{day}". Defaults to
+	// "{row}".
+	RowKey string
+	// TimestampField is the CSV header of the cell timestamps of mapped CSV
+	// records, in milliseconds.
+	TimestampField string
+	// Timestamps writes cells with their timestamps through MutateRowsTs.
+	// Otherwise cells are written at the current time. It is implied by
+	// TimestampField.
+	Timestamps bool
+
+	// BatchSize is the number of rows per MutateRows call, defaults to 100
+	BatchSize int
+	// Concurrency is the number of concurrent MutateRows calls, defaults to 1
+	Concurrency int
+	// RateLimit caps the rows written per second, zero disables it
+	RateLimit float64
+	// Checkpoint is a file keeping the number of imported records. An import
+	// resumes after the records it lists.
+	Checkpoint string
+	// DryRun only parses and validates the input
+	DryRun bool
+}
+
+// ImportStats reports the progress of Import.
+type ImportStats struct {
+	// Records is the number of input records read, including skipped ones
+	Records int
+	// Skipped is the number of records skipped by the checkpoint, or for
+	// having no cells, e.g. CSV records whose fields are all empty
+	Skipped int
+	// Rows and Cells count the written, or validated, rows and cells
+	Rows  int
+	Cells int
+}
+
+// importRow is a row read from an import
+type importRow struct {
+	// index is the index of the record in the input
+	index int
+	row   Text
+	cells []importCell
+}
+
+type importCell struct {
+	column Text
+	value  []byte
+	// ts is the cell timestamp, zero if unknown
+	ts int64
+}
+
+// validate checks a row before it is written.
+func (r *importRow) validate() error {
+	if len(r.row) == 0 {
+		return fmt.Errorf("record %d: empty row key", r.index)
+	}
+	for _, c := range r.cells {
+		if err := validateColumn(c.column); err != nil {
+			return fmt.Errorf("record %d: %v", r.index, err)
+		}
+	}
+	return nil
+}
+
+// validateColumn checks that column is in family:qualifier form.
+func validateColumn(column Text) error {
+	i := strings.IndexByte(string(column), ':')
+	if i <= 0 {
+		return fmt.Errorf("column %q is not in family:qualifier form", string(column))
+	}
+	return nil
+}
+
+// rowReader reads the rows of an import
+type rowReader interface {
+	// next returns the next row, or io.EOF
+	next() (*importRow, error)
+}
+
+// newRowReader creates the rowReader of opts.
+func newRowReader(r io.Reader, opts ImportOptions) (rowReader, error) {
+	switch opts.Format {
+	case ExportCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		header, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		if opts.ColumnMap == nil {
+			if strings.Join(header, ",") != strings.Join(csvHeader, ",") {
+				return nil, fmt.Errorf("unexpected csv header %v, set a column map to import it", header)
+			}
+			return &csvRowReader{r: cr, enc: opts.Encoding}, nil
+		}
+		return newMappedCSVReader(cr, header, opts)
+	case ExportBinary:
+		br := bufio.NewReader(r)
+		return &binaryRowReader{
+			r:     br,
+			iprot: thrift.NewTCompactProtocol(thrift.NewStreamTransportR(br)),
+		}, nil
+	}
+	return &jsonRowReader{dec: json.NewDecoder(r), enc: opts.Encoding}, nil
+}
+
+type jsonRowReader struct {
+	dec   *json.Decoder
+	enc   ValueEncoding
+	count int
+}
+
+func (r *jsonRowReader) next() (*importRow, error) {
+	var er exportRow
+	if err := r.dec.Decode(&er); err != nil {
+		return nil, err
+	}
+	row := &importRow{index: r.count}
+	r.count++
+	var err error
+	if row.row, err = decodeValue(er.Row, r.enc); err != nil {
+		return nil, fmt.Errorf("record %d: %v", row.index, err)
+	}
+	for _, c := range er.Cells {
+		cell := importCell{ts: c.Timestamp}
+		if cell.column, err = decodeValue(c.Column, r.enc); err != nil {
+			return nil, fmt.Errorf("record %d: %v", row.index, err)
+		}
+		if cell.value, err = decodeValue(c.Value, r.enc); err != nil {
+			return nil, fmt.Errorf("record %d: %v", row.index, err)
+		}
+		row.cells = append(row.cells, cell)
+	}
+	return row, nil
+}
+
+// csvRowReader reads CSV in the layout of Export, one cell per record
+type csvRowReader struct {
+	r     *csv.Reader
+	enc   ValueEncoding
+	count int
+}
+
+func (r *csvRowReader) next() (*importRow, error) {
+	fields, err := r.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := &importRow{index: r.count}
+	r.count++
+	if len(fields) != len(csvHeader) {
+		return nil, fmt.Errorf("record %d: expected %d fields, got %d", row.index, len(csvHeader), len(fields))
+	}
+	cell := importCell{}
+	if row.row, err = decodeValue(fields[0], r.enc); err != nil {
+		return nil, fmt.Errorf("record %d: %v", row.index, err)
+	}
+	if cell.column, err = decodeValue(fields[1], r.enc); err != nil {
+		return nil, fmt.Errorf("record %d: %v", row.index, err)
+	}
+	if cell.ts, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+		return nil, fmt.Errorf("record %d: %v", row.index, err)
+	}
+	if cell.value, err = decodeValue(fields[3], r.enc); err != nil {
+		return nil, fmt.Errorf("record %d: %v", row.index, err)
+	}
+	row.cells = []importCell{cell}
+	return row, nil
+}
+
+// keyTemplate builds row keys from record fields
+type keyTemplate struct {
+	// literals and fields alternate, starting with a literal
+	literals []string
+	fields   []int
+}
+
+// parseKeyTemplate parses a row key template referencing the given headers.
+func parseKeyTemplate(s string, header []string) (*keyTemplate, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[h] = i
+	}
+	t := &keyTemplate{}
+	for {
+		open := strings.IndexByte(s, '{')
+		if open < 0 {
+			t.literals = append(t.literals, s)
+			return t, nil
+		}
+		end := strings.IndexByte(s[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated field in row key template %q", s)
+		}
+		name := s[open+1 : open+end]
+		i, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("row key field %q is not a csv header", name)
+		}
+		t.literals = append(t.literals, s[:open])
+		t.fields = append(t.fields, i)
+		s = s[open+end+1:]
+	}
+}
+
+// build returns the row key of a record.
+func (t *keyTemplate) build(fields []string) Text {
+	var key []byte
+	for i, lit := range t.literals {
+		key = append(key, lit...)
+		if i < len(t.fields) && t.fields[i] < len(fields) {
+			key = append(key, fields[t.fields[i]]...)
+		}
+	}
+	return key
+}
+
+// mappedCSVReader reads CSV records whose fields map to columns
+type mappedCSVReader struct {
+	r       *csv.Reader
+	key     *keyTemplate
+	columns []Text
+	tsField int
+	count   int
+}
+
+func newMappedCSVReader(r *csv.Reader, header []string, opts ImportOptions) (*mappedCSVReader, error) {
+	keySpec := opts.RowKey
+	if keySpec == "" {
+		keySpec = "{row}"
+	}
+	key, err := parseKeyTemplate(keySpec, header)
+	if err != nil {
+		return nil, err
+	}
+	m := &mappedCSVReader{
+		r:       r,
+		key:     key,
+		columns: make([]Text, len(header)),
+		tsField: -1,
+	}
+	mapped := 0
+	for i, h := range header {
+		if h == opts.TimestampField {
+			m.tsField = i
+		}
+		if column, ok := opts.ColumnMap[h]; ok {
+			if err := validateColumn(Text(column)); err != nil {
+				return nil, err
+			}
+			m.columns[i] = Text(column)
+			mapped++
+		}
+	}
+	if mapped != len(opts.ColumnMap) {
+		return nil, fmt.Errorf("column map references headers missing from %v", header)
+	}
+	if opts.TimestampField != "" && m.tsField < 0 {
+		return nil, fmt.Errorf("timestamp field %q is not a csv header", opts.TimestampField)
+	}
+	return m, nil
+}
+
+func (m *mappedCSVReader) next() (*importRow, error) {
+	fields, err := m.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := &importRow{index: m.count, row: m.key.build(fields)}
+	m.count++
+	if allEmpty(fields) {
+		// a record of separators only has no cells and is skipped by Import
+		return row, nil
+	}
+	var ts int64
+	if m.tsField >= 0 && m.tsField < len(fields) {
+		if ts, err = strconv.ParseInt(fields[m.tsField], 10, 64); err != nil {
+			return nil, fmt.Errorf("record %d: %v", row.index, err)
+		}
+	}
+	for i, column := range m.columns {
+		if column == nil || i >= len(fields) || fields[i] == "" {
+			continue
+		}
+		row.cells = append(row.cells, importCell{column: column, value: []byte(fields[i]), ts: ts})
+	}
+	return row, nil
+}
+
+func allEmpty(fields []string) bool {
+	for _, f := range fields {
+		if f != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// binaryRowReader reads TRowResult_ structs written by ExportBinary
+type binaryRowReader struct {
+	r     *bufio.Reader
+	iprot thrift.TProtocol
+	count int
+}
+
+func (r *binaryRowReader) next() (*importRow, error) {
+	if _, err := r.r.Peek(1); err != nil {
+		return nil, err
+	}
+	result := NewTRowResult_()
+	if err := result.Read(r.iprot); err != nil {
+		return nil, err
+	}
+	row := &importRow{index: r.count, row: result.Row}
+	r.count++
+	for _, col := range sortedColumns(result) {
+		row.cells = append(row.cells, importCell{
+			column: col.ColumnName,
+			value:  col.Cell.Value,
+			ts:     col.Cell.Timestamp,
+		})
+	}
+	return row, nil
+}
+
+// readCheckpoint returns the number of records imported according to the
+// checkpoint file, zero if it does not exist.
+func readCheckpoint(name string) (int, error) {
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// writeCheckpoint atomically replaces the checkpoint file.
+func writeCheckpoint(name string, records int) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(records)+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// rateLimiter spaces out writes to a rate of rows per second
+type rateLimiter struct {
+	mu   sync.Mutex
+	rate float64
+	next time.Time
+}
+
+// wait blocks until n more rows may be written.
+func (l *rateLimiter) wait(n int) {
+	if l.rate <= 0 {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	l.mu.Unlock()
+	time.Sleep(time.Until(at))
+}
+
+// importBatch is a batch of rows written by one worker
+type importBatch struct {
+	// first and end delimit the records [first, end) of the batch
+	first, end int
+	rows       []*importRow
+}
+
+// importer tracks the progress of an import
+type importer struct {
+	h          Hbase
+	table      Text
+	opts       ImportOptions
+	timestamps bool
+
+	mu    sync.Mutex
+	err   error
+	done  map[int]int // first record of a completed batch -> its end
+	saved int         // records below saved are imported
+}
+
+// write writes the rows of a batch, grouped by timestamp if needed.
+func (im *importer) write(b *importBatch) error {
+	groups := map[int64][]*BatchMutation{}
+	var order []int64
+	for _, r := range b.rows {
+		byTs := map[int64]*BatchMutation{}
+		for _, c := range r.cells {
+			ts := int64(0)
+			if im.timestamps {
+				ts = c.ts
+			}
+			bm, ok := byTs[ts]
+			if !ok {
+				bm = &BatchMutation{Row: r.row}
+				byTs[ts] = bm
+				if len(groups[ts]) == 0 {
+					order = append(order, ts)
+				}
+				groups[ts] = append(groups[ts], bm)
+			}
+			m := NewMutation()
+			m.Column = c.column
+			m.Value = c.value
+			bm.Mutations = append(bm.Mutations, m)
+		}
+	}
+	for _, ts := range order {
+		var err error
+		if ts == 0 {
+			err = im.h.MutateRows(im.table, groups[ts], nil)
+		} else {
+			err = im.h.MutateRowsTs(im.table, groups[ts], ts, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// complete records a written batch and advances the checkpoint over all
+// contiguous completed batches.
+func (im *importer) complete(b *importBatch) error {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.done[b.first] = b.end
+	advanced := false
+	for {
+		end, ok := im.done[im.saved]
+		if !ok {
+			break
+		}
+		delete(im.done, im.saved)
+		im.saved = end
+		advanced = true
+	}
+	if advanced && im.opts.Checkpoint != "" && !im.opts.DryRun {
+		return writeCheckpoint(im.opts.Checkpoint, im.saved)
+	}
+	return nil
+}
+
+// fail records the first error of the import.
+func (im *importer) fail(err error) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	if im.err == nil {
+		im.err = err
+	}
+}
+
+func (im *importer) failed() error {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	return im.err
+}
+
+// Import reads rows from r and writes them to table with MutateRows. On
+// error, the checkpoint, if any, lists the records known to be imported so
+// that the import can be resumed.
+func Import(h Hbase, table Text, r io.Reader, opts ImportOptions) (ImportStats, error) {
+	var stats ImportStats
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	skip := 0
+	if opts.Checkpoint != "" {
+		var err error
+		if skip, err = readCheckpoint(opts.Checkpoint); err != nil {
+			return stats, err
+		}
+	}
+	rows, err := newRowReader(r, opts)
+	if err != nil {
+		return stats, err
+	}
+
+	im := &importer{
+		h:          h,
+		table:      table,
+		opts:       opts,
+		timestamps: opts.Timestamps || opts.TimestampField != "",
+		done:       map[int]int{},
+		saved:      skip,
+	}
+	limiter := &rateLimiter{rate: opts.RateLimit}
+	batches := make(chan *importBatch)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// batches already dispatched are written even after a failure
+			// so that the checkpoint covers them
+			for b := range batches {
+				limiter.wait(len(b.rows))
+				if err := im.write(b); err != nil {
+					im.fail(fmt.Errorf("records %d-%d: %v", b.first, b.end-1, err))
+					continue
+				}
+				if err := im.complete(b); err != nil {
+					im.fail(err)
+				}
+			}
+		}()
+	}
+
+	batch := &importBatch{first: skip}
+	for im.failed() == nil {
+		row, err := rows.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			im.fail(err)
+			break
+		}
+		stats.Records++
+		if row.index < skip || len(row.cells) == 0 {
+			stats.Skipped++
+			continue
+		}
+		if err := row.validate(); err != nil {
+			im.fail(err)
+			break
+		}
+		stats.Rows++
+		stats.Cells += len(row.cells)
+		if opts.DryRun {
+			continue
+		}
+		batch.rows = append(batch.rows, row)
+		batch.end = row.index + 1
+		if len(batch.rows) >= opts.BatchSize {
+			batches <- batch
+			batch = &importBatch{first: batch.end}
+		}
+	}
+	if len(batch.rows) > 0 && im.failed() == nil {
+		batches <- batch
+	}
+	close(batches)
+	wg.Wait()
+	return stats, im.failed()
+}
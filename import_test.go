@@ -0,0 +1,112 @@
+package hbase
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportRoundTrip(t *testing.T) {
+	src := newMemHbase()
+	src.createTable("table")
+	src.put("table", "a1", "cf:q", "hello", 10)
+	src.put("table", "a2", "cf:q", "\x00\xff", 11)
+	src.put("table", "a2", "cf:r", "x", 12)
+
+	for _, format := range []ExportFormat{ExportJSON, ExportCSV, ExportBinary} {
+		var buf bytes.Buffer
+		w := NewRowWriter(&buf, format, EncodeBase64)
+		if _, err := Export(src, Text("table"), ExportOptions{}, w); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+
+		dst := newMemHbase()
+		dst.createTable("table")
+		stats, err := Import(dst, Text("table"), &buf, ImportOptions{
+			Format:      format,
+			Encoding:    EncodeBase64,
+			Timestamps:  true,
+			BatchSize:   1,
+			Concurrency: 2,
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+		if stats.Cells != 3 {
+			t.Fatalf("%v: unexpected stats %+v", format, stats)
+		}
+		r, _ := dst.GetRow(Text("table"), Text("a2"), nil)
+		if len(r) != 1 || string(r[0].Columns["cf:q"].Value) != "\x00\xff" || r[0].Columns["cf:r"].Timestamp != 12 {
+			t.Fatalf("%v: unexpected row %v", format, r)
+		}
+	}
+}
+
+func TestImportMappedCSV(t *testing.T) {
+	input := "user,day,clicks,ts\nu1,mon,3,100\n,,,\nu2,tue,,200\n"
+	m := newMemHbase()
+	m.createTable("table")
+	opts := ImportOptions{
+		Format:         ExportCSV,
+		ColumnMap:      map[string]string{"clicks": "cf:clicks", "day": "cf:day"},
+		RowKey:         "{user}#{day}",
+		TimestampField: "ts",
+		DryRun:         true,
+	}
+	stats, err := Import(m, Text("table"), strings.NewReader(input), opts)
+	if err != nil || stats.Rows != 2 || stats.Cells != 3 || stats.Skipped != 1 || len(m.tables["table"].rows) != 0 {
+		t.Fatalf("unexpected dry run %+v: %v", stats, err)
+	}
+
+	opts.DryRun = false
+	if _, err := Import(m, Text("table"), strings.NewReader(input), opts); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := m.GetRow(Text("table"), Text("u1#mon"), nil)
+	if len(r) != 1 || string(r[0].Columns["cf:clicks"].Value) != "3" || r[0].Columns["cf:clicks"].Timestamp != 100 {
+		t.Fatalf("unexpected row %v", r)
+	}
+	r, _ = m.GetRow(Text("table"), Text("u2#tue"), nil)
+	if len(r) != 1 || r[0].Columns["cf:clicks"] != nil {
+		t.Fatalf("unexpected row %v", r)
+	}
+
+	opts.ColumnMap = map[string]string{"clicks": "clicks"}
+	if _, err := Import(m, Text("table"), strings.NewReader(input), opts); err == nil {
+		t.Fatal("expected an invalid column error")
+	}
+}
+
+func TestImportCheckpoint(t *testing.T) {
+	input := `{"row":"r1","cells":[{"column":"cf:q","value":"1","timestamp":1}]}
+{"row":"r2","cells":[{"column":"cf:q","value":"2","timestamp":1}]}
+{"row":"","cells":[{"column":"cf:q","value":"3","timestamp":1}]}
+`
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	m := newMemHbase()
+	m.createTable("table")
+	opts := ImportOptions{Encoding: EncodeUTF8, BatchSize: 1, Checkpoint: checkpoint}
+	if _, err := Import(m, Text("table"), strings.NewReader(input), opts); err == nil {
+		t.Fatal("expected an empty row key error")
+	}
+	if n, err := readCheckpoint(checkpoint); err != nil || n != 2 {
+		t.Fatalf("unexpected checkpoint %d: %v", n, err)
+	}
+
+	input = strings.Replace(input, `"row":""`, `"row":"r3"`, 1)
+	delete(m.tables["table"].rows, "r1")
+	stats, err := Import(m, Text("table"), strings.NewReader(input), opts)
+	if err != nil || stats.Skipped != 2 || stats.Rows != 1 {
+		t.Fatalf("unexpected resume %+v: %v", stats, err)
+	}
+	if _, ok := m.tables["table"].rows["r1"]; ok {
+		t.Fatal("resumed import rewrote a checkpointed record")
+	}
+	data, _ := os.ReadFile(checkpoint)
+	if string(data) != "3\n" {
+		t.Fatalf("unexpected checkpoint %q", data)
+	}
+}
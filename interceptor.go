@@ -0,0 +1,655 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Invoker performs an Hbase call. args points to the thrift arguments
+// struct of method, e.g. *GetArgs for Get, and result to the matching
+// result struct, e.g. *GetResult, whose Success field is set on return.
+type Invoker func(ctx context.Context, method string, args, result interface{}) error
+
+// Interceptor intercepts an Hbase call. It may inspect or modify args
+// before calling invoker and result after it, or short-circuit the call by
+// filling result (or returning an error) without calling invoker at all.
+type Interceptor func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error
+
+// InterceptedHbase implements Hbase by running every call through a chain
+// of interceptors before it reaches the wrapped Hbase.
+type InterceptedHbase struct {
+	hbase        Hbase
+	interceptors []Interceptor
+	ctx          context.Context
+}
+
+// Intercept wraps h, which may be a WrapConn, Mux, HbaseClient or any other
+// Hbase implementation, with the given interceptors. The first interceptor
+// is the outermost one, i.e. it sees a call first and its result last.
+func Intercept(h Hbase, interceptors ...Interceptor) *InterceptedHbase {
+	return &InterceptedHbase{
+		hbase:        h,
+		interceptors: interceptors,
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext returns a shallow copy of c whose calls pass ctx to the
+// interceptors.
+func (c *InterceptedHbase) WithContext(ctx context.Context) *InterceptedHbase {
+	cc := *c
+	cc.ctx = ctx
+	return &cc
+}
+
+// invoke runs a call through the interceptor chain.
+func (c *InterceptedHbase) invoke(method string, args, result interface{}) error {
+	return chainInterceptors(c.interceptors, c.call)(c.ctx, method, args, result)
+}
+
+// chainInterceptors builds an Invoker which runs interceptors in order and
+// ends with invoker.
+func chainInterceptors(interceptors []Interceptor, invoker Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, method string, args, result interface{}) error {
+			return interceptor(ctx, method, args, result, next)
+		}
+	}
+	return invoker
+}
+
+// call is the innermost Invoker. It calls method on the wrapped Hbase with
+// the fields of args as arguments and stores the returned value into the
+// Success field of result.
+func (c *InterceptedHbase) call(ctx context.Context, method string, args, result interface{}) error {
+	argv := reflect.ValueOf(args).Elem()
+	in := make([]interface{}, argv.NumField())
+	for i := range in {
+		in[i] = argv.Field(i).Interface()
+	}
+	err, results := invokeMethodViaReflection(c.hbase, method, in...)
+	if err != nil {
+		return err
+	}
+	if len(results) == 1 {
+		return setSuccess(result, results[0])
+	}
+	return nil
+}
+
+// resultSuccess returns the Success field of a thrift result struct, or an
+// invalid reflect.Value if it has none.
+func resultSuccess(result interface{}) reflect.Value {
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return v.Elem().FieldByName("Success")
+}
+
+// setSuccess stores v into the Success field of a thrift result struct.
+// Scalar results are kept as pointers by thrift and are allocated here.
+func setSuccess(result interface{}, v reflect.Value) error {
+	field := resultSuccess(result)
+	if !field.IsValid() {
+		return fmt.Errorf("result %T has no success field", result)
+	}
+	if field.Kind() == reflect.Ptr && v.Kind() != reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(v.Convert(field.Type().Elem()))
+		v = ptr
+	}
+	field.Set(v)
+	return nil
+}
+
+// Append runs HBase Append through the interceptor chain.
+func (c *InterceptedHbase) Append(append *TAppend) ([]*TCell, error) {
+	args := &AppendArgs{
+		Append: append,
+	}
+	result := NewAppendResult()
+	if err := c.invoke("Append", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// AtomicIncrement runs HBase AtomicIncrement through the interceptor chain.
+func (c *InterceptedHbase) AtomicIncrement(tableName Text, row Text, column Text, value int64) (int64, error) {
+	args := &AtomicIncrementArgs{
+		TableName: tableName,
+		Row:       row,
+		Column:    column,
+		Value:     value,
+	}
+	result := NewAtomicIncrementResult()
+	if err := c.invoke("AtomicIncrement", args, result); err != nil {
+		return int64(0), err
+	}
+	return result.GetSuccess(), nil
+}
+
+// CheckAndPut runs HBase CheckAndPut through the interceptor chain.
+func (c *InterceptedHbase) CheckAndPut(tableName Text, row Text, column Text, value Text, mput *Mutation, attributes map[string]Text) (bool, error) {
+	args := &CheckAndPutArgs{
+		TableName:  tableName,
+		Row:        row,
+		Column:     column,
+		Value:      value,
+		Mput:       mput,
+		Attributes: attributes,
+	}
+	result := NewCheckAndPutResult()
+	if err := c.invoke("CheckAndPut", args, result); err != nil {
+		return false, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// Compact runs HBase Compact through the interceptor chain.
+func (c *InterceptedHbase) Compact(tableNameOrRegionName Bytes) error {
+	args := &CompactArgs{
+		TableNameOrRegionName: tableNameOrRegionName,
+	}
+	return c.invoke("Compact", args, NewCompactResult())
+}
+
+// CreateTable runs HBase CreateTable through the interceptor chain.
+func (c *InterceptedHbase) CreateTable(tableName Text, columnFamilies []*ColumnDescriptor) error {
+	args := &CreateTableArgs{
+		TableName:      tableName,
+		ColumnFamilies: columnFamilies,
+	}
+	return c.invoke("CreateTable", args, NewCreateTableResult())
+}
+
+// DeleteAll runs HBase DeleteAll through the interceptor chain.
+func (c *InterceptedHbase) DeleteAll(tableName Text, row Text, column Text, attributes map[string]Text) error {
+	args := &DeleteAllArgs{
+		TableName:  tableName,
+		Row:        row,
+		Column:     column,
+		Attributes: attributes,
+	}
+	return c.invoke("DeleteAll", args, NewDeleteAllResult())
+}
+
+// DeleteAllRow runs HBase DeleteAllRow through the interceptor chain.
+func (c *InterceptedHbase) DeleteAllRow(tableName Text, row Text, attributes map[string]Text) error {
+	args := &DeleteAllRowArgs{
+		TableName:  tableName,
+		Row:        row,
+		Attributes: attributes,
+	}
+	return c.invoke("DeleteAllRow", args, NewDeleteAllRowResult())
+}
+
+// DeleteAllRowTs runs HBase DeleteAllRowTs through the interceptor chain.
+func (c *InterceptedHbase) DeleteAllRowTs(tableName Text, row Text, timestamp int64, attributes map[string]Text) error {
+	args := &DeleteAllRowTsArgs{
+		TableName:  tableName,
+		Row:        row,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	return c.invoke("DeleteAllRowTs", args, NewDeleteAllRowTsResult())
+}
+
+// DeleteAllTs runs HBase DeleteAllTs through the interceptor chain.
+func (c *InterceptedHbase) DeleteAllTs(tableName Text, row Text, column Text, timestamp int64, attributes map[string]Text) error {
+	args := &DeleteAllTsArgs{
+		TableName:  tableName,
+		Row:        row,
+		Column:     column,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	return c.invoke("DeleteAllTs", args, NewDeleteAllTsResult())
+}
+
+// DeleteTable runs HBase DeleteTable through the interceptor chain.
+func (c *InterceptedHbase) DeleteTable(tableName Text) error {
+	args := &DeleteTableArgs{
+		TableName: tableName,
+	}
+	return c.invoke("DeleteTable", args, NewDeleteTableResult())
+}
+
+// DisableTable runs HBase DisableTable through the interceptor chain.
+func (c *InterceptedHbase) DisableTable(tableName Bytes) error {
+	args := &DisableTableArgs{
+		TableName: tableName,
+	}
+	return c.invoke("DisableTable", args, NewDisableTableResult())
+}
+
+// EnableTable runs HBase EnableTable through the interceptor chain.
+func (c *InterceptedHbase) EnableTable(tableName Bytes) error {
+	args := &EnableTableArgs{
+		TableName: tableName,
+	}
+	return c.invoke("EnableTable", args, NewEnableTableResult())
+}
+
+// Get runs HBase Get through the interceptor chain.
+func (c *InterceptedHbase) Get(tableName Text, row Text, column Text, attributes map[string]Text) ([]*TCell, error) {
+	args := &GetArgs{
+		TableName:  tableName,
+		Row:        row,
+		Column:     column,
+		Attributes: attributes,
+	}
+	result := NewGetResult()
+	if err := c.invoke("Get", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetColumnDescriptors runs HBase GetColumnDescriptors through the interceptor chain.
+func (c *InterceptedHbase) GetColumnDescriptors(tableName Text) (map[string]*ColumnDescriptor, error) {
+	args := &GetColumnDescriptorsArgs{
+		TableName: tableName,
+	}
+	result := NewGetColumnDescriptorsResult()
+	if err := c.invoke("GetColumnDescriptors", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRegionInfo runs HBase GetRegionInfo through the interceptor chain.
+func (c *InterceptedHbase) GetRegionInfo(row Text) (*TRegionInfo, error) {
+	args := &GetRegionInfoArgs{
+		Row: row,
+	}
+	result := NewGetRegionInfoResult()
+	if err := c.invoke("GetRegionInfo", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRow runs HBase GetRow through the interceptor chain.
+func (c *InterceptedHbase) GetRow(tableName Text, row Text, attributes map[string]Text) ([]*TRowResult_, error) {
+	args := &GetRowArgs{
+		TableName:  tableName,
+		Row:        row,
+		Attributes: attributes,
+	}
+	result := NewGetRowResult()
+	if err := c.invoke("GetRow", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRowTs runs HBase GetRowTs through the interceptor chain.
+func (c *InterceptedHbase) GetRowTs(tableName Text, row Text, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	args := &GetRowTsArgs{
+		TableName:  tableName,
+		Row:        row,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	result := NewGetRowTsResult()
+	if err := c.invoke("GetRowTs", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRowWithColumns runs HBase GetRowWithColumns through the interceptor chain.
+func (c *InterceptedHbase) GetRowWithColumns(tableName Text, row Text, columns [][]byte, attributes map[string]Text) ([]*TRowResult_, error) {
+	args := &GetRowWithColumnsArgs{
+		TableName:  tableName,
+		Row:        row,
+		Columns:    columns,
+		Attributes: attributes,
+	}
+	result := NewGetRowWithColumnsResult()
+	if err := c.invoke("GetRowWithColumns", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRowWithColumnsTs runs HBase GetRowWithColumnsTs through the interceptor chain.
+func (c *InterceptedHbase) GetRowWithColumnsTs(tableName Text, row Text, columns [][]byte, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	args := &GetRowWithColumnsTsArgs{
+		TableName:  tableName,
+		Row:        row,
+		Columns:    columns,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	result := NewGetRowWithColumnsTsResult()
+	if err := c.invoke("GetRowWithColumnsTs", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRows runs HBase GetRows through the interceptor chain.
+func (c *InterceptedHbase) GetRows(tableName Text, rows [][]byte, attributes map[string]Text) ([]*TRowResult_, error) {
+	args := &GetRowsArgs{
+		TableName:  tableName,
+		Rows:       rows,
+		Attributes: attributes,
+	}
+	result := NewGetRowsResult()
+	if err := c.invoke("GetRows", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRowsTs runs HBase GetRowsTs through the interceptor chain.
+func (c *InterceptedHbase) GetRowsTs(tableName Text, rows [][]byte, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	args := &GetRowsTsArgs{
+		TableName:  tableName,
+		Rows:       rows,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	result := NewGetRowsTsResult()
+	if err := c.invoke("GetRowsTs", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRowsWithColumns runs HBase GetRowsWithColumns through the interceptor chain.
+func (c *InterceptedHbase) GetRowsWithColumns(tableName Text, rows [][]byte, columns [][]byte, attributes map[string]Text) ([]*TRowResult_, error) {
+	args := &GetRowsWithColumnsArgs{
+		TableName:  tableName,
+		Rows:       rows,
+		Columns:    columns,
+		Attributes: attributes,
+	}
+	result := NewGetRowsWithColumnsResult()
+	if err := c.invoke("GetRowsWithColumns", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetRowsWithColumnsTs runs HBase GetRowsWithColumnsTs through the interceptor chain.
+func (c *InterceptedHbase) GetRowsWithColumnsTs(tableName Text, rows [][]byte, columns [][]byte, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	args := &GetRowsWithColumnsTsArgs{
+		TableName:  tableName,
+		Rows:       rows,
+		Columns:    columns,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	result := NewGetRowsWithColumnsTsResult()
+	if err := c.invoke("GetRowsWithColumnsTs", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetTableNames runs HBase GetTableNames through the interceptor chain.
+func (c *InterceptedHbase) GetTableNames() ([][]byte, error) {
+	args := NewGetTableNamesArgs()
+	result := NewGetTableNamesResult()
+	if err := c.invoke("GetTableNames", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetTableRegions runs HBase GetTableRegions through the interceptor chain.
+func (c *InterceptedHbase) GetTableRegions(tableName Text) ([]*TRegionInfo, error) {
+	args := &GetTableRegionsArgs{
+		TableName: tableName,
+	}
+	result := NewGetTableRegionsResult()
+	if err := c.invoke("GetTableRegions", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetVer runs HBase GetVer through the interceptor chain.
+func (c *InterceptedHbase) GetVer(tableName Text, row Text, column Text, numVersions int32, attributes map[string]Text) ([]*TCell, error) {
+	args := &GetVerArgs{
+		TableName:   tableName,
+		Row:         row,
+		Column:      column,
+		NumVersions: numVersions,
+		Attributes:  attributes,
+	}
+	result := NewGetVerResult()
+	if err := c.invoke("GetVer", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// GetVerTs runs HBase GetVerTs through the interceptor chain.
+func (c *InterceptedHbase) GetVerTs(tableName Text, row Text, column Text, timestamp int64, numVersions int32, attributes map[string]Text) ([]*TCell, error) {
+	args := &GetVerTsArgs{
+		TableName:   tableName,
+		Row:         row,
+		Column:      column,
+		Timestamp:   timestamp,
+		NumVersions: numVersions,
+		Attributes:  attributes,
+	}
+	result := NewGetVerTsResult()
+	if err := c.invoke("GetVerTs", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// Increment runs HBase Increment through the interceptor chain.
+func (c *InterceptedHbase) Increment(increment *TIncrement) error {
+	args := &IncrementArgs{
+		Increment: increment,
+	}
+	return c.invoke("Increment", args, NewIncrementResult())
+}
+
+// IncrementRows runs HBase IncrementRows through the interceptor chain.
+func (c *InterceptedHbase) IncrementRows(increments []*TIncrement) error {
+	args := &IncrementRowsArgs{
+		Increments: increments,
+	}
+	return c.invoke("IncrementRows", args, NewIncrementRowsResult())
+}
+
+// IsTableEnabled runs HBase IsTableEnabled through the interceptor chain.
+func (c *InterceptedHbase) IsTableEnabled(tableName Bytes) (bool, error) {
+	args := &IsTableEnabledArgs{
+		TableName: tableName,
+	}
+	result := NewIsTableEnabledResult()
+	if err := c.invoke("IsTableEnabled", args, result); err != nil {
+		return false, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// MajorCompact runs HBase MajorCompact through the interceptor chain.
+func (c *InterceptedHbase) MajorCompact(tableNameOrRegionName Bytes) error {
+	args := &MajorCompactArgs{
+		TableNameOrRegionName: tableNameOrRegionName,
+	}
+	return c.invoke("MajorCompact", args, NewMajorCompactResult())
+}
+
+// MutateRow runs HBase MutateRow through the interceptor chain.
+func (c *InterceptedHbase) MutateRow(tableName Text, row Text, mutations []*Mutation, attributes map[string]Text) error {
+	args := &MutateRowArgs{
+		TableName:  tableName,
+		Row:        row,
+		Mutations:  mutations,
+		Attributes: attributes,
+	}
+	return c.invoke("MutateRow", args, NewMutateRowResult())
+}
+
+// MutateRowTs runs HBase MutateRowTs through the interceptor chain.
+func (c *InterceptedHbase) MutateRowTs(tableName Text, row Text, mutations []*Mutation, timestamp int64, attributes map[string]Text) error {
+	args := &MutateRowTsArgs{
+		TableName:  tableName,
+		Row:        row,
+		Mutations:  mutations,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	return c.invoke("MutateRowTs", args, NewMutateRowTsResult())
+}
+
+// MutateRows runs HBase MutateRows through the interceptor chain.
+func (c *InterceptedHbase) MutateRows(tableName Text, rowBatches []*BatchMutation, attributes map[string]Text) error {
+	args := &MutateRowsArgs{
+		TableName:  tableName,
+		RowBatches: rowBatches,
+		Attributes: attributes,
+	}
+	return c.invoke("MutateRows", args, NewMutateRowsResult())
+}
+
+// MutateRowsTs runs HBase MutateRowsTs through the interceptor chain.
+func (c *InterceptedHbase) MutateRowsTs(tableName Text, rowBatches []*BatchMutation, timestamp int64, attributes map[string]Text) error {
+	args := &MutateRowsTsArgs{
+		TableName:  tableName,
+		RowBatches: rowBatches,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	return c.invoke("MutateRowsTs", args, NewMutateRowsTsResult())
+}
+
+// ScannerClose runs HBase ScannerClose through the interceptor chain.
+func (c *InterceptedHbase) ScannerClose(id ScannerID) error {
+	args := &ScannerCloseArgs{
+		Id: id,
+	}
+	return c.invoke("ScannerClose", args, NewScannerCloseResult())
+}
+
+// ScannerGet runs HBase ScannerGet through the interceptor chain.
+func (c *InterceptedHbase) ScannerGet(id ScannerID) ([]*TRowResult_, error) {
+	args := &ScannerGetArgs{
+		Id: id,
+	}
+	result := NewScannerGetResult()
+	if err := c.invoke("ScannerGet", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// ScannerGetList runs HBase ScannerGetList through the interceptor chain.
+func (c *InterceptedHbase) ScannerGetList(id ScannerID, nbRows int32) ([]*TRowResult_, error) {
+	args := &ScannerGetListArgs{
+		Id:     id,
+		NbRows: nbRows,
+	}
+	result := NewScannerGetListResult()
+	if err := c.invoke("ScannerGetList", args, result); err != nil {
+		return nil, err
+	}
+	return result.GetSuccess(), nil
+}
+
+// ScannerOpen runs HBase ScannerOpen through the interceptor chain.
+func (c *InterceptedHbase) ScannerOpen(tableName Text, startRow Text, columns [][]byte, attributes map[string]Text) (ScannerID, error) {
+	args := &ScannerOpenArgs{
+		TableName:  tableName,
+		StartRow:   startRow,
+		Columns:    columns,
+		Attributes: attributes,
+	}
+	result := NewScannerOpenResult()
+	if err := c.invoke("ScannerOpen", args, result); err != nil {
+		return ScannerID(0), err
+	}
+	return result.GetSuccess(), nil
+}
+
+// ScannerOpenTs runs HBase ScannerOpenTs through the interceptor chain.
+func (c *InterceptedHbase) ScannerOpenTs(tableName Text, startRow Text, columns [][]byte, timestamp int64, attributes map[string]Text) (ScannerID, error) {
+	args := &ScannerOpenTsArgs{
+		TableName:  tableName,
+		StartRow:   startRow,
+		Columns:    columns,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	result := NewScannerOpenTsResult()
+	if err := c.invoke("ScannerOpenTs", args, result); err != nil {
+		return ScannerID(0), err
+	}
+	return result.GetSuccess(), nil
+}
+
+// ScannerOpenWithPrefix runs HBase ScannerOpenWithPrefix through the interceptor chain.
+func (c *InterceptedHbase) ScannerOpenWithPrefix(tableName Text, startAndPrefix Text, columns [][]byte, attributes map[string]Text) (ScannerID, error) {
+	args := &ScannerOpenWithPrefixArgs{
+		TableName:      tableName,
+		StartAndPrefix: startAndPrefix,
+		Columns:        columns,
+		Attributes:     attributes,
+	}
+	result := NewScannerOpenWithPrefixResult()
+	if err := c.invoke("ScannerOpenWithPrefix", args, result); err != nil {
+		return ScannerID(0), err
+	}
+	return result.GetSuccess(), nil
+}
+
+// ScannerOpenWithScan runs HBase ScannerOpenWithScan through the interceptor chain.
+func (c *InterceptedHbase) ScannerOpenWithScan(tableName Text, scan *TScan, attributes map[string]Text) (ScannerID, error) {
+	args := &ScannerOpenWithScanArgs{
+		TableName:  tableName,
+		Scan:       scan,
+		Attributes: attributes,
+	}
+	result := NewScannerOpenWithScanResult()
+	if err := c.invoke("ScannerOpenWithScan", args, result); err != nil {
+		return ScannerID(0), err
+	}
+	return result.GetSuccess(), nil
+}
+
+// ScannerOpenWithStop runs HBase ScannerOpenWithStop through the interceptor chain.
+func (c *InterceptedHbase) ScannerOpenWithStop(tableName Text, startRow Text, stopRow Text, columns [][]byte, attributes map[string]Text) (ScannerID, error) {
+	args := &ScannerOpenWithStopArgs{
+		TableName:  tableName,
+		StartRow:   startRow,
+		StopRow:    stopRow,
+		Columns:    columns,
+		Attributes: attributes,
+	}
+	result := NewScannerOpenWithStopResult()
+	if err := c.invoke("ScannerOpenWithStop", args, result); err != nil {
+		return ScannerID(0), err
+	}
+	return result.GetSuccess(), nil
+}
+
+// ScannerOpenWithStopTs runs HBase ScannerOpenWithStopTs through the interceptor chain.
+func (c *InterceptedHbase) ScannerOpenWithStopTs(tableName Text, startRow Text, stopRow Text, columns [][]byte, timestamp int64, attributes map[string]Text) (ScannerID, error) {
+	args := &ScannerOpenWithStopTsArgs{
+		TableName:  tableName,
+		StartRow:   startRow,
+		StopRow:    stopRow,
+		Columns:    columns,
+		Timestamp:  timestamp,
+		Attributes: attributes,
+	}
+	result := NewScannerOpenWithStopTsResult()
+	if err := c.invoke("ScannerOpenWithStopTs", args, result); err != nil {
+		return ScannerID(0), err
+	}
+	return result.GetSuccess(), nil
+}
@@ -0,0 +1,75 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+var (
+	_ Hbase = (*WrapConn)(nil)
+	_ Hbase = (*InterceptedHbase)(nil)
+)
+
+type ctxKey struct{}
+
+func TestIntercept(t *testing.T) {
+	mockHbase := &MockHbase{}
+	mockHbase.On("Get", Text("ns_table"), Text("row"), Text("cf:q"), map[string]Text(nil)).
+		Return([]*TCell{{Value: Bytes("v")}}, nil)
+	mockHbase.On("IsTableEnabled", Bytes("table")).Return(true, nil)
+
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+			order = append(order, name+">"+method)
+			err := invoker(ctx, method, args, result)
+			order = append(order, name+"<"+method)
+			return err
+		}
+	}
+	// prefix table names of Get calls
+	rewrite := func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		if a, ok := args.(*GetArgs); ok {
+			a.TableName = append(Text("ns_"), a.TableName...)
+		}
+		return invoker(ctx, method, args, result)
+	}
+	// answer Compact without reaching the wrapped Hbase
+	deny := func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		if method == "Compact" {
+			return &IllegalArgument{Message: "compaction disabled"}
+		}
+		if v := ctx.Value(ctxKey{}); v != nil && method == "IsTableEnabled" {
+			result.(*IsTableEnabledResult).Success = new(bool)
+			return nil
+		}
+		return invoker(ctx, method, args, result)
+	}
+
+	h := Intercept(mockHbase, trace("outer"), trace("inner"), rewrite, deny)
+
+	cells, err := h.Get(Text("table"), Text("row"), Text("cf:q"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cells) != 1 || string(cells[0].Value) != "v" {
+		t.Fatalf("unexpected cells: %v", cells)
+	}
+	if fmt.Sprint(order) != "[outer>Get inner>Get inner<Get outer<Get]" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+
+	if err := h.Compact(Bytes("table")); err == nil {
+		t.Fatal("expected compaction to be denied")
+	}
+	mockHbase.AssertNotCalled(t, "Compact", Bytes("table"))
+
+	if ok, err := h.IsTableEnabled(Bytes("table")); err != nil || !ok {
+		t.Fatalf("unexpected result: %v, %v", ok, err)
+	}
+	ctx := context.WithValue(context.Background(), ctxKey{}, true)
+	if ok, err := h.WithContext(ctx).IsTableEnabled(Bytes("table")); err != nil || ok {
+		t.Fatalf("unexpected result: %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,182 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultMaxKeyLen is the default length row keys are truncated to in logs
+const defaultMaxKeyLen = 64
+
+// maxSlowListLen is the number of elements of lists, such as row keys or
+// mutations, logged for slow calls
+const maxSlowListLen = 16
+
+// LogConfig configures LoggingInterceptor.
+type LogConfig struct {
+	// Logger receives the logs, slog.Default() is used if nil
+	Logger *slog.Logger
+	// Level is the level of regular call logs. Failed calls are logged at
+	// error level and slow calls at warn level.
+	Level slog.Level
+	// SlowThreshold marks calls taking at least this long as slow. Slow calls
+	// are always logged along with their full arguments, keys and values
+	// escaped and truncated like row keys. Zero disables it.
+	SlowThreshold time.Duration
+	// SampleEvery logs one of every SampleEvery regular calls per method.
+	// Failed and slow calls are never sampled out. Zero or one logs all.
+	SampleEvery int
+	// MaxKeyLen is the length row keys and other binary arguments are
+	// truncated to, defaults to 64
+	MaxKeyLen int
+}
+
+// LoggingInterceptor returns an Interceptor which logs every call with its
+// method, table, row key, duration and error, e.g.
+//
+//	conn := Intercept(NewConn(rawConn), LoggingInterceptor(LogConfig{
+//		SlowThreshold: 100 * time.Millisecond,
+//		SampleEvery:   100,
+//	}))
+func LoggingInterceptor(cfg LogConfig) Interceptor {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.MaxKeyLen <= 0 {
+		cfg.MaxKeyLen = defaultMaxKeyLen
+	}
+	// counters holds a *uint64 call counter per method for sampling
+	var counters sync.Map
+
+	return func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		start := time.Now()
+		err := invoker(ctx, method, args, result)
+		elapsed := time.Since(start)
+
+		slow := cfg.SlowThreshold > 0 && elapsed >= cfg.SlowThreshold
+		level := cfg.Level
+		switch {
+		case err != nil:
+			level = slog.LevelError
+		case slow:
+			level = slog.LevelWarn
+		case cfg.SampleEvery > 1:
+			v, _ := counters.LoadOrStore(method, new(uint64))
+			if atomic.AddUint64(v.(*uint64), 1)%uint64(cfg.SampleEvery) != 1 {
+				return err
+			}
+		}
+		if !cfg.Logger.Enabled(ctx, level) {
+			return err
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", method),
+			slog.Duration("duration", elapsed),
+		}
+		if table := TableOf(args); table != nil {
+			attrs = append(attrs, slog.String("table", ToStringBinary(table)))
+		}
+		if row := RowOf(args); row != nil {
+			attrs = append(attrs, slog.String("row", escapeKey(row, cfg.MaxKeyLen)))
+		}
+		if n := RowCount(args); n > 1 {
+			attrs = append(attrs, slog.Int("rows", n))
+		}
+		if err != nil {
+			attrs = append(attrs,
+				slog.String("error", err.Error()),
+				slog.String("error_class", ErrorClass(err)))
+		}
+		msg := "hbase call"
+		if slow {
+			msg = "slow hbase call"
+			attrs = append(attrs, slog.String("args", renderArgs(args, cfg.MaxKeyLen)))
+		}
+		cfg.Logger.LogAttrs(ctx, level, msg, attrs...)
+		return err
+	}
+}
+
+// renderArgs renders the fields of a call's args like %+v does, except
+// that binary values are rendered with escapeKey and lists are cut after
+// maxSlowListLen elements.
+func renderArgs(args interface{}, maxKeyLen int) string {
+	var b strings.Builder
+	renderValue(&b, reflect.ValueOf(args), maxKeyLen)
+	return b.String()
+}
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+func renderValue(b *strings.Builder, v reflect.Value, maxKeyLen int) {
+	switch {
+	case !v.IsValid():
+		b.WriteString("<nil>")
+	case v.Kind() == reflect.Slice && v.Type().ConvertibleTo(bytesType):
+		b.WriteString(escapeKey(v.Bytes(), maxKeyLen))
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			b.WriteString("<nil>")
+			return
+		}
+		renderValue(b, v.Elem(), maxKeyLen)
+	case v.Kind() == reflect.Struct:
+		b.WriteByte('{')
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(v.Type().Field(i).Name)
+			b.WriteByte(':')
+			renderValue(b, v.Field(i), maxKeyLen)
+		}
+		b.WriteByte('}')
+	case v.Kind() == reflect.Slice:
+		b.WriteByte('[')
+		n := v.Len()
+		for i := 0; i < n && i < maxSlowListLen; i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			renderValue(b, v.Index(i), maxKeyLen)
+		}
+		if n > maxSlowListLen {
+			fmt.Fprintf(b, " ... (%d more)", n-maxSlowListLen)
+		}
+		b.WriteByte(']')
+	case v.Kind() == reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		b.WriteString("map[")
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			renderValue(b, k, maxKeyLen)
+			b.WriteByte(':')
+			renderValue(b, v.MapIndex(k), maxKeyLen)
+		}
+		b.WriteByte(']')
+	case v.Kind() == reflect.String:
+		b.WriteString(ToStringBinary([]byte(v.String())))
+	default:
+		fmt.Fprint(b, v.Interface())
+	}
+}
+
+// escapeKey renders key with ToStringBinary and truncates it to max bytes
+// of the original key.
+func escapeKey(key []byte, max int) string {
+	if len(key) <= max {
+		return ToStringBinary(key)
+	}
+	return ToStringBinary(key[:max]) + "..."
+}
@@ -0,0 +1,93 @@
+package hbase
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLoggingInterceptor(t *testing.T) {
+	mockHbase := &MockHbase{}
+	mockHbase.On("DeleteAllRow", Text("table"), Text("\x00row\xff"), map[string]Text(nil)).Return(nil)
+	mockHbase.On("DeleteTable", Text("table")).Return(&IOError{Message: "boom"})
+
+	var buf bytes.Buffer
+	h := Intercept(mockHbase, LoggingInterceptor(LogConfig{
+		Logger:      slog.New(slog.NewTextHandler(&buf, nil)),
+		Level:       slog.LevelInfo,
+		SampleEvery: 3,
+	}))
+
+	for i := 0; i < 4; i++ {
+		if err := h.DeleteAllRow(Text("table"), Text("\x00row\xff"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.DeleteTable(Text("table")); err == nil {
+		t.Fatal("expected error")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 2 sampled calls and 1 error, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[0], `row=\x00row\xFF`) {
+		t.Fatalf("row key is not escaped: %s", lines[0])
+	}
+	if !strings.Contains(lines[2], "level=ERROR") || !strings.Contains(lines[2], "error_class=io") {
+		t.Fatalf("unexpected error log: %s", lines[2])
+	}
+}
+
+func TestLoggingInterceptorSlowCall(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("t\x01")
+	var buf bytes.Buffer
+	h := Intercept(m, LoggingInterceptor(LogConfig{
+		Logger:        slog.New(slog.NewTextHandler(&buf, nil)),
+		SlowThreshold: 1,
+	}))
+
+	var rows [][]byte
+	for i := 0; i < 20; i++ {
+		rows = append(rows, []byte{0, byte(i), 0xff})
+	}
+	if _, err := h.GetRows(Text("t\x01"), rows, nil); err != nil {
+		t.Fatal(err)
+	}
+	line := buf.String()
+	if !strings.Contains(line, "slow hbase call") || !strings.Contains(line, `table=t\x01`) {
+		t.Fatalf("unexpected slow call log: %s", line)
+	}
+	// the text handler quotes the args, doubling their backslashes
+	if !strings.Contains(line, `args="{TableName:t\\x01 Rows:[\\x00\\x00\\xFF \\x00\\x01\\xFF`) ||
+		!strings.Contains(line, `\\x00\\x0F\\xFF ... (4 more)] Attributes:map[]}"`) || strings.ContainsAny(line, "\x00\xff") {
+		t.Fatalf("row keys are not escaped: %q", line)
+	}
+
+	buf.Reset()
+	mutations := []*Mutation{{Column: Text("cf:a"), Value: bytes.Repeat([]byte{0xff}, 100), WriteToWAL: true}}
+	if err := h.MutateRowTs(Text("t\x01"), Text("r"), mutations, 42, map[string]Text{"b": Text("2"), "a": Text("1")}); err != nil {
+		t.Fatal(err)
+	}
+	want := `args="{TableName:t\\x01 Row:r Mutations:[{IsDelete:false Column:cf:a Value:` +
+		strings.Repeat(`\\xFF`, 64) + `... WriteToWAL:true}] Timestamp:42 Attributes:map[a:1 b:2]}"`
+	if line := buf.String(); !strings.Contains(line, want) {
+		t.Fatalf("unexpected args: %s", line)
+	}
+
+	buf.Reset()
+	scan := NewTScan()
+	scan.StartRow = Text("\x00a")
+	id, err := h.ScannerOpenWithScan(Text("t\x01"), scan, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.ScannerClose(id)
+	if line := buf.String(); !strings.Contains(line, `Scan:{StartRow:\\x00a StopRow: Timestamp:<nil>`) ||
+		!strings.Contains(line, fmt.Sprintf(`args={Id:%d}`, id)) {
+		t.Fatalf("unexpected args: %s", line)
+	}
+}
@@ -0,0 +1,322 @@
+package hbase
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// memHbase is an in-memory Hbase used by tests. It implements the table,
+// get, mutate and scan calls which the tests exercise; other calls fall
+// through to the embedded MockHbase. Like the thrift gateway, scans and
+// gets return the latest version of every column before their timestamp.
+type memHbase struct {
+	MockHbase
+
+	mu       sync.Mutex
+	tables   map[string]*memTable
+	scanners map[ScannerID]*memScanner
+	lastID   ScannerID
+	clock    int64
+}
+
+type memTable struct {
+	// rows maps row keys to columns to versions, latest first
+	rows   map[string]map[string][]*TCell
+	splits []string
+}
+
+type memScanner struct {
+	rows []*TRowResult_
+}
+
+func newMemHbase() *memHbase {
+	return &memHbase{
+		tables:   map[string]*memTable{},
+		scanners: map[ScannerID]*memScanner{},
+		clock:    1000,
+	}
+}
+
+// createTable creates table with regions split at the given keys.
+func (m *memHbase) createTable(table string, splits ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tables[table] = &memTable{rows: map[string]map[string][]*TCell{}, splits: splits}
+}
+
+// put writes a cell, at the next clock tick if ts is zero.
+func (m *memHbase) put(table, row, column, value string, ts int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.write(table, row, &Mutation{Column: Text(column), Value: Text(value)}, ts)
+}
+
+func (m *memHbase) write(table, row string, mut *Mutation, ts int64) {
+	t := m.tables[table]
+	if ts == 0 {
+		m.clock++
+		ts = m.clock
+	}
+	if t.rows[row] == nil {
+		t.rows[row] = map[string][]*TCell{}
+	}
+	column := string(mut.Column)
+	if mut.IsDelete {
+		var kept []*TCell
+		for _, c := range t.rows[row][column] {
+			if c.Timestamp > ts {
+				kept = append(kept, c)
+			}
+		}
+		t.rows[row][column] = kept
+		return
+	}
+	versions := append(t.rows[row][column], &TCell{Value: Bytes(mut.Value), Timestamp: ts})
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].Timestamp > versions[j].Timestamp })
+	t.rows[row][column] = versions
+}
+
+// result returns the latest cells of row before ts in the given columns.
+func (m *memHbase) result(t *memTable, row string, columns [][]byte, ts int64) *TRowResult_ {
+	r := &TRowResult_{Row: Text(row), Columns: map[string]*TCell{}}
+	for column, versions := range t.rows[row] {
+		if !columnSelected(column, columns) {
+			continue
+		}
+		for _, c := range versions {
+			if ts == 0 || c.Timestamp < ts {
+				r.Columns[column] = c
+				break
+			}
+		}
+	}
+	if len(r.Columns) == 0 {
+		return nil
+	}
+	return r
+}
+
+func columnSelected(column string, columns [][]byte) bool {
+	if len(columns) == 0 {
+		return true
+	}
+	for _, c := range columns {
+		if column == string(c) || (!bytes.Contains(c, []byte(":")) && strings.HasPrefix(column, string(c)+":")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *memHbase) GetTableNames() ([][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names [][]byte
+	for name := range m.tables {
+		names = append(names, []byte(name))
+	}
+	sort.Slice(names, func(i, j int) bool { return bytes.Compare(names[i], names[j]) < 0 })
+	return names, nil
+}
+
+func (m *memHbase) GetTableRegions(tableName Text) ([]*TRegionInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tables[string(tableName)]
+	if !ok {
+		return nil, &IOError{Message: "table not found"}
+	}
+	bounds := append(append([]string{""}, t.splits...), "")
+	var regions []*TRegionInfo
+	for i := 0; i+1 < len(bounds); i++ {
+		regions = append(regions, &TRegionInfo{
+			StartKey: Text(bounds[i]),
+			EndKey:   Text(bounds[i+1]),
+			Id:       int64(i),
+			Name:     Text(string(tableName) + "," + bounds[i]),
+		})
+	}
+	return regions, nil
+}
+
+func (m *memHbase) GetRow(tableName, row Text, attributes map[string]Text) ([]*TRowResult_, error) {
+	return m.GetRowsWithColumnsTs(tableName, [][]byte{row}, nil, 0, attributes)
+}
+
+func (m *memHbase) GetRowTs(tableName, row Text, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	return m.GetRowsWithColumnsTs(tableName, [][]byte{row}, nil, timestamp, attributes)
+}
+
+func (m *memHbase) GetRows(tableName Text, rows [][]byte, attributes map[string]Text) ([]*TRowResult_, error) {
+	return m.GetRowsWithColumnsTs(tableName, rows, nil, 0, attributes)
+}
+
+func (m *memHbase) GetRowsWithColumnsTs(tableName Text, rows [][]byte, columns [][]byte, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tables[string(tableName)]
+	if !ok {
+		return nil, &IOError{Message: "table not found"}
+	}
+	var results []*TRowResult_
+	for _, row := range rows {
+		if r := m.result(t, string(row), columns, timestamp); r != nil {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+func (m *memHbase) GetVer(tableName, row, column Text, numVersions int32, attributes map[string]Text) ([]*TCell, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tables[string(tableName)]
+	if !ok {
+		return nil, &IOError{Message: "table not found"}
+	}
+	versions := t.rows[string(row)][string(column)]
+	if len(versions) > int(numVersions) {
+		versions = versions[:numVersions]
+	}
+	// copy, as callers such as value transforms replace result cells
+	return append([]*TCell(nil), versions...), nil
+}
+
+func (m *memHbase) MutateRow(tableName, row Text, mutations []*Mutation, attributes map[string]Text) error {
+	return m.MutateRowsTs(tableName, []*BatchMutation{{Row: row, Mutations: mutations}}, 0, attributes)
+}
+
+func (m *memHbase) MutateRowTs(tableName, row Text, mutations []*Mutation, timestamp int64, attributes map[string]Text) error {
+	return m.MutateRowsTs(tableName, []*BatchMutation{{Row: row, Mutations: mutations}}, timestamp, attributes)
+}
+
+func (m *memHbase) MutateRows(tableName Text, rowBatches []*BatchMutation, attributes map[string]Text) error {
+	return m.MutateRowsTs(tableName, rowBatches, 0, attributes)
+}
+
+func (m *memHbase) MutateRowsTs(tableName Text, rowBatches []*BatchMutation, timestamp int64, attributes map[string]Text) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tables[string(tableName)]; !ok {
+		return &IOError{Message: "table not found"}
+	}
+	for _, b := range rowBatches {
+		for _, mut := range b.Mutations {
+			if len(mut.Column) == 0 {
+				return &IllegalArgument{Message: "empty column"}
+			}
+			m.write(string(tableName), string(b.Row), mut, timestamp)
+		}
+	}
+	return nil
+}
+
+func (m *memHbase) DeleteAllRow(tableName, row Text, attributes map[string]Text) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tables[string(tableName)].rows, string(row))
+	return nil
+}
+
+func (m *memHbase) ScannerOpenWithScan(tableName Text, scan *TScan, attributes map[string]Text) (ScannerID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tables[string(tableName)]
+	if !ok {
+		return 0, &IOError{Message: "table not found"}
+	}
+	var keys []string
+	for key := range t.rows {
+		if key < string(scan.StartRow) || (len(scan.StopRow) > 0 && key >= string(scan.StopRow)) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s := &memScanner{}
+	filter := string(scan.FilterString)
+	for _, key := range keys {
+		r := m.result(t, key, scan.Columns, scan.GetTimestamp())
+		if r == nil {
+			continue
+		}
+		if strings.Contains(filter, "KeyOnlyFilter") {
+			for name, c := range r.Columns {
+				r.Columns[name] = &TCell{Timestamp: c.Timestamp}
+			}
+		}
+		if strings.Contains(filter, "FirstKeyOnlyFilter") {
+			first := sortedColumns(r)[0]
+			r.Columns = map[string]*TCell{string(first.ColumnName): first.Cell}
+		}
+		if scan.GetSortColumns() {
+			r.SortedColumns = sortedColumns(r)
+			r.Columns = nil
+		}
+		s.rows = append(s.rows, r)
+	}
+	m.lastID++
+	m.scanners[m.lastID] = s
+	return m.lastID, nil
+}
+
+func (m *memHbase) ScannerGetList(id ScannerID, nbRows int32) ([]*TRowResult_, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.scanners[id]
+	if !ok {
+		return nil, &IllegalArgument{Message: "scanner not found"}
+	}
+	n := int(nbRows)
+	if n > len(s.rows) {
+		n = len(s.rows)
+	}
+	rows := s.rows[:n]
+	s.rows = s.rows[n:]
+	return rows, nil
+}
+
+func (m *memHbase) ScannerGet(id ScannerID) ([]*TRowResult_, error) {
+	return m.ScannerGetList(id, 1)
+}
+
+func (m *memHbase) ScannerClose(id ScannerID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.scanners[id]; !ok {
+		return &IllegalArgument{Message: "scanner not found"}
+	}
+	delete(m.scanners, id)
+	return nil
+}
+
+func (m *memHbase) Get(tableName, row, column Text, attributes map[string]Text) ([]*TCell, error) {
+	return m.GetVer(tableName, row, column, 1, attributes)
+}
+
+func (m *memHbase) GetRowsWithColumns(tableName Text, rows [][]byte, columns [][]byte, attributes map[string]Text) ([]*TRowResult_, error) {
+	return m.GetRowsWithColumnsTs(tableName, rows, columns, 0, attributes)
+}
+
+// CheckAndPut applies mput if the latest value of column is value, or if
+// value is empty and column has no value.
+func (m *memHbase) CheckAndPut(tableName, row, column Text, value Text, mput *Mutation, attributes map[string]Text) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tables[string(tableName)]
+	if !ok {
+		return false, &IOError{Message: "table not found"}
+	}
+	var current []byte
+	if versions := t.rows[string(row)][string(column)]; len(versions) > 0 {
+		current = versions[0].Value
+	}
+	if !bytes.Equal(current, value) {
+		return false, nil
+	}
+	m.write(string(tableName), string(row), mput, 0)
+	return true, nil
+}
@@ -0,0 +1,139 @@
+package hbase
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// Metrics receives measurements of Hbase calls. Implementations must be
+// safe for concurrent use. See NewExpvarMetrics and package hbaseprom for
+// ready-made implementations.
+type Metrics interface {
+	// ObserveLatency records the duration of a call
+	ObserveLatency(method string, d time.Duration)
+	// IncError counts a failed call by its error class, see ErrorClass
+	IncError(method, class string)
+	// AddBytes records the estimated payload sizes written and read by a call
+	AddBytes(method string, written, read int)
+	// AddRows records the number of rows returned by a call
+	AddRows(method string, rows int)
+	// AddInFlight adjusts the number of calls in flight
+	AddInFlight(method string, delta int)
+}
+
+// Error classes reported by ErrorClass
+const (
+	ErrClassIO              = "io"
+	ErrClassIllegalArgument = "illegal_argument"
+	ErrClassAlreadyExists   = "already_exists"
+	ErrClassApplication     = "application"
+	ErrClassTransport       = "transport"
+	ErrClassProtocol        = "protocol"
+	ErrClassOther           = "other"
+)
+
+// ErrorClass classifies err into one of the ErrClass constants. It returns
+// an empty string for a nil error.
+func ErrorClass(err error) string {
+	switch err.(type) {
+	case nil:
+		return ""
+	case *IOError:
+		return ErrClassIO
+	case *IllegalArgument:
+		return ErrClassIllegalArgument
+	case *AlreadyExists:
+		return ErrClassAlreadyExists
+	case thrift.TApplicationException:
+		return ErrClassApplication
+	case thrift.TTransportException:
+		return ErrClassTransport
+	case thrift.TProtocolException:
+		return ErrClassProtocol
+	}
+	return ErrClassOther
+}
+
+// Instrument wraps h so that every call is reported to m.
+func Instrument(h Hbase, m Metrics) Hbase {
+	return Intercept(h, MetricsInterceptor(m))
+}
+
+// MetricsInterceptor returns an Interceptor which reports the latency,
+// payload sizes, returned rows and errors of every call to m.
+func MetricsInterceptor(m Metrics) Interceptor {
+	return func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		m.AddInFlight(method, 1)
+		defer m.AddInFlight(method, -1)
+
+		start := time.Now()
+		err := invoker(ctx, method, args, result)
+		m.ObserveLatency(method, time.Since(start))
+
+		read := 0
+		if success := resultSuccess(result); success.IsValid() {
+			read = wireSize(success)
+		}
+		if rows, ok := ResultRows(result); ok {
+			m.AddRows(method, rows)
+		}
+		m.AddBytes(method, wireSize(reflect.ValueOf(args)), read)
+		if err != nil {
+			m.IncError(method, ErrorClass(err))
+		}
+		return err
+	}
+}
+
+// wireSize estimates the size of v encoded by the thrift binary protocol.
+func wireSize(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return wireSize(v.Elem())
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1
+	case reflect.Int16:
+		return 2
+	case reflect.Int32:
+		return 4
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return 8
+	case reflect.String:
+		return 4 + v.Len()
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return 4 + v.Len()
+		}
+		// element type and list size
+		n := 5
+		for i := 0; i < v.Len(); i++ {
+			n += wireSize(v.Index(i))
+		}
+		return n
+	case reflect.Map:
+		// key type, value type and map size
+		n := 6
+		for _, k := range v.MapKeys() {
+			n += wireSize(k) + wireSize(v.MapIndex(k))
+		}
+		return n
+	case reflect.Struct:
+		// field stop
+		n := 1
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			// field type and id
+			n += 3 + wireSize(v.Field(i))
+		}
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package hbase
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics is a Metrics that keeps what it receives
+type recordingMetrics struct {
+	mu       sync.Mutex
+	calls    map[string]int
+	errors   map[string]int
+	written  map[string]int
+	read     map[string]int
+	rows     map[string]int
+	inFlight map[string]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		calls:    map[string]int{},
+		errors:   map[string]int{},
+		written:  map[string]int{},
+		read:     map[string]int{},
+		rows:     map[string]int{},
+		inFlight: map[string]int{},
+	}
+}
+
+func (m *recordingMetrics) ObserveLatency(method string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[method]++
+}
+
+func (m *recordingMetrics) IncError(method, class string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[method+"."+class]++
+}
+
+func (m *recordingMetrics) AddBytes(method string, written, read int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.written[method] += written
+	m.read[method] += read
+}
+
+func (m *recordingMetrics) AddRows(method string, rows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rows[method] += rows
+}
+
+func (m *recordingMetrics) AddInFlight(method string, delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[method] += delta
+}
+
+func TestInstrument(t *testing.T) {
+	mockHbase := &MockHbase{}
+	mockHbase.On("ScannerGetList", ScannerID(1), int32(10)).
+		Return([]*TRowResult_{{Row: Text("a")}, {Row: Text("b")}}, nil)
+	mockHbase.On("Get", Text("table"), Text("row"), Text("cf:q"), map[string]Text(nil)).
+		Return([]*TCell(nil), &IOError{Message: "boom"})
+	mockHbase.On("DeleteTable", Text("table")).Return(errors.New("boom"))
+
+	m := newRecordingMetrics()
+	h := Instrument(mockHbase, m)
+
+	if _, err := h.ScannerGetList(ScannerID(1), 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Get(Text("table"), Text("row"), Text("cf:q"), nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := h.DeleteTable(Text("table")); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if m.calls["ScannerGetList"] != 1 || m.calls["Get"] != 1 || m.calls["DeleteTable"] != 1 {
+		t.Fatalf("unexpected calls: %v", m.calls)
+	}
+	if m.rows["ScannerGetList"] != 2 {
+		t.Fatalf("expected 2 rows, got %v", m.rows)
+	}
+	if m.errors["Get.io"] != 1 || m.errors["DeleteTable.other"] != 1 {
+		t.Fatalf("unexpected errors: %v", m.errors)
+	}
+	if m.written["Get"] == 0 || m.read["ScannerGetList"] == 0 {
+		t.Fatalf("expected payload sizes, got written %v read %v", m.written, m.read)
+	}
+	for method, n := range m.inFlight {
+		if n != 0 {
+			t.Fatalf("%s still has %d calls in flight", method, n)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package hbase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MutationBuilder builds the mutations of a row, setting WriteToWAL like
+// NewMutation does and validating columns. Errors are reported by Build
+// and Batch.
+//
+//	mutations, err := NewMutationBuilder(row).
+//		Put("cf", "name", []byte("value")).
+//		Delete("cf", "old").
+//		Build()
+type MutationBuilder struct {
+	row       Text
+	mutations []*Mutation
+	skipWAL   bool
+	err       error
+
+	// columns and families record the mutated columns and deleted families
+	columns  map[string]bool
+	families map[string]bool
+}
+
+// NewMutationBuilder creates a MutationBuilder for row.
+func NewMutationBuilder(row Text) *MutationBuilder {
+	return &MutationBuilder{
+		row:      row,
+		columns:  map[string]bool{},
+		families: map[string]bool{},
+	}
+}
+
+// fail records the first error of the builder.
+func (b *MutationBuilder) fail(format string, args ...interface{}) *MutationBuilder {
+	if b.err == nil {
+		b.err = fmt.Errorf(format, args...)
+	}
+	return b
+}
+
+// validFamily checks a family name.
+func validFamily(family string) error {
+	if family == "" {
+		return fmt.Errorf("empty family")
+	}
+	if strings.IndexByte(family, ':') >= 0 {
+		return fmt.Errorf("family %q contains a colon, pass the family and qualifier separately", family)
+	}
+	return nil
+}
+
+// add adds a mutation of family:qualifier, checking it is the only one of
+// its column.
+func (b *MutationBuilder) add(family, qualifier string, value []byte, isDelete bool) *MutationBuilder {
+	if err := validFamily(family); err != nil {
+		return b.fail("%v", err)
+	}
+	column := family + ":" + qualifier
+	if b.columns[column] {
+		return b.fail("duplicate mutation of column %q", column)
+	}
+	if b.families[family] {
+		return b.fail("mutation of column %q of deleted family %q", column, family)
+	}
+	b.columns[column] = true
+	m := NewMutation()
+	m.Column = Text(column)
+	m.Value = value
+	m.IsDelete = isDelete
+	b.mutations = append(b.mutations, m)
+	return b
+}
+
+// Put writes value to family:qualifier.
+func (b *MutationBuilder) Put(family, qualifier string, value []byte) *MutationBuilder {
+	return b.add(family, qualifier, value, false)
+}
+
+// Delete deletes all versions of family:qualifier.
+func (b *MutationBuilder) Delete(family, qualifier string) *MutationBuilder {
+	return b.add(family, qualifier, nil, true)
+}
+
+// DeleteFamily deletes all columns of family.
+func (b *MutationBuilder) DeleteFamily(family string) *MutationBuilder {
+	if err := validFamily(family); err != nil {
+		return b.fail("%v", err)
+	}
+	if b.families[family] {
+		return b.fail("duplicate delete of family %q", family)
+	}
+	for column := range b.columns {
+		if strings.HasPrefix(column, family+":") {
+			return b.fail("mutation of column %q of deleted family %q", column, family)
+		}
+	}
+	b.families[family] = true
+	// the gateway deletes a whole family when the column has no colon
+	m := NewMutation()
+	m.Column = Text(family)
+	m.IsDelete = true
+	b.mutations = append(b.mutations, m)
+	return b
+}
+
+// SkipWAL disables the write-ahead log for all mutations, trading
+// durability for speed.
+func (b *MutationBuilder) SkipWAL() *MutationBuilder {
+	b.skipWAL = true
+	return b
+}
+
+// Build returns the mutations for MutateRow, or the first error found. The
+// mutations are copies, so that the builder can keep being used.
+func (b *MutationBuilder) Build() ([]*Mutation, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.row) == 0 {
+		return nil, fmt.Errorf("empty row")
+	}
+	if len(b.mutations) == 0 {
+		return nil, fmt.Errorf("no mutations of row %s", ToStringBinary(b.row))
+	}
+	mutations := make([]*Mutation, len(b.mutations))
+	for i, m := range b.mutations {
+		c := *m
+		c.WriteToWAL = !b.skipWAL
+		mutations[i] = &c
+	}
+	return mutations, nil
+}
+
+// Batch returns the BatchMutation of the row for MutateRows.
+func (b *MutationBuilder) Batch() (*BatchMutation, error) {
+	mutations, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &BatchMutation{Row: b.row, Mutations: mutations}, nil
+}
+
+// Validate checks that the families of the mutations exist in table.
+func (b *MutationBuilder) Validate(h Hbase, table Text) error {
+	descriptors, err := h.GetColumnDescriptors(table)
+	if err != nil {
+		return err
+	}
+	families := map[string]bool{}
+	for name := range descriptors {
+		// the gateway returns families with a trailing colon
+		families[strings.TrimSuffix(name, ":")] = true
+	}
+	for _, m := range b.mutations {
+		if family := familyOf(m.Column); !families[family] {
+			return fmt.Errorf("table %q has no family %q", []byte(table), family)
+		}
+	}
+	return nil
+}
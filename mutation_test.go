@@ -0,0 +1,65 @@
+package hbase
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMutationBuilder(t *testing.T) {
+	mutations, err := NewMutationBuilder(Text("row")).
+		Put("cf", "a", []byte("1")).
+		Put("cf", "", []byte("empty qualifier")).
+		Delete("cf", "b").
+		DeleteFamily("old").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mutations) != 4 || string(mutations[1].Column) != "cf:" || !mutations[2].IsDelete ||
+		string(mutations[3].Column) != "old" || !mutations[0].WriteToWAL {
+		t.Fatalf("unexpected mutations %v", mutations)
+	}
+
+	// built mutations are copies which later builds do not modify
+	b := NewMutationBuilder(Text("row")).Put("cf", "a", nil)
+	built, _ := b.Build()
+	batch, err := b.SkipWAL().Batch()
+	if err != nil || string(batch.Row) != "row" || batch.Mutations[0].WriteToWAL {
+		t.Fatalf("unexpected batch %v: %v", batch, err)
+	}
+	if !built[0].WriteToWAL || built[0] == batch.Mutations[0] {
+		t.Fatalf("built mutations were modified %v", built)
+	}
+	built[0].Value = Text("changed")
+	if again, _ := b.Build(); again[0].Value != nil {
+		t.Fatalf("unexpected mutations %v", again)
+	}
+
+	for expected, b := range map[string]*MutationBuilder{
+		"empty family":       NewMutationBuilder(Text("row")).Put("", "a", nil),
+		"contains a colon":   NewMutationBuilder(Text("row")).Put("cf:a", "", nil),
+		"duplicate mutation": NewMutationBuilder(Text("row")).Put("cf", "a", nil).Delete("cf", "a"),
+		"deleted family":     NewMutationBuilder(Text("row")).Put("cf", "a", nil).DeleteFamily("cf"),
+		"duplicate delete":   NewMutationBuilder(Text("row")).DeleteFamily("cf").DeleteFamily("cf"),
+		"no mutations":       NewMutationBuilder(Text("row")),
+	} {
+		if _, err := b.Build(); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected a %q error, got %v", expected, err)
+		}
+	}
+	if _, err := NewMutationBuilder(nil).Put("cf", "a", nil).Build(); err == nil || !strings.Contains(err.Error(), "empty row") {
+		t.Fatalf("expected an empty row error, got %v", err)
+	}
+	if _, err := NewMutationBuilder(nil).Put("cf", "a", nil).Batch(); err == nil {
+		t.Fatal("expected an empty row error")
+	}
+
+	m := &MockHbase{}
+	m.On("GetColumnDescriptors", Text("table")).Return(map[string]*ColumnDescriptor{"cf:": {Name: Text("cf:")}}, nil)
+	if err := NewMutationBuilder(Text("row")).Put("cf", "a", nil).Validate(m, Text("table")); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewMutationBuilder(Text("row")).DeleteFamily("nope").Validate(m, Text("table")); err == nil {
+		t.Fatal("expected an unknown family error")
+	}
+}
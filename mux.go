@@ -0,0 +1,507 @@
+package hbase
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Mux multiplexes HBase calls over a fixed set of thrift connections.
+// Unlike WrapConn, which serializes every call on a single connection, Mux
+// runs each call on the least busy connection, so one slow call only blocks
+// the callers that queue behind it. Scanners are pinned to the connection
+// that opened them because scanner ids are only meaningful to the gateway
+// which issued them.
+//
+// Mux exposes the same method set as WrapConn and can be used in its place.
+type Mux struct {
+	conns []*muxConn
+	next  uint32
+
+	// mu guards scanners and lastScanner
+	mu          sync.Mutex
+	scanners    map[ScannerID]muxScanner
+	lastScanner ScannerID
+
+	observer func(cmd string, wait time.Duration)
+}
+
+// muxConn is a single connection of a Mux
+type muxConn struct {
+	client *clientCloser
+	// load is the number of calls running or queued on the connection
+	load int32
+}
+
+// muxScanner locates a scanner opened through a Mux
+type muxScanner struct {
+	conn *muxConn
+	id   ScannerID
+}
+
+// NewMux creates a Mux over size connections created by factory, e.g. the
+// one returned by ThriftClientFactory.
+func NewMux(factory func() (io.Closer, error), size int) (*Mux, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid mux size: %d", size)
+	}
+	m := &Mux{
+		scanners: make(map[ScannerID]muxScanner),
+	}
+	for i := 0; i < size; i++ {
+		rawConn, err := factory()
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+		client, ok := rawConn.(*clientCloser)
+		if !ok {
+			rawConn.Close()
+			m.Close()
+			return nil, fmt.Errorf("unsupported connection type: %T", rawConn)
+		}
+		m.conns = append(m.conns, &muxConn{client: client})
+	}
+	return m, nil
+}
+
+// SetQueueObserver registers f to be called with the time each call spent
+// waiting for its connection. It must be called before the Mux is used.
+func (m *Mux) SetQueueObserver(f func(cmd string, wait time.Duration)) {
+	m.observer = f
+}
+
+// Close closes all underlying connections.
+func (m *Mux) Close() error {
+	var firstErr error
+	for _, c := range m.conns {
+		if err := c.client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// pick returns the connection with the fewest running or queued calls.
+// Ties are broken in round robin order.
+func (m *Mux) pick() *muxConn {
+	n := len(m.conns)
+	start := int(atomic.AddUint32(&m.next, 1) % uint32(n))
+	best := m.conns[start]
+	for i := 1; i < n && atomic.LoadInt32(&best.load) > 0; i++ {
+		c := m.conns[(start+i)%n]
+		if atomic.LoadInt32(&c.load) < atomic.LoadInt32(&best.load) {
+			best = c
+		}
+	}
+	return best
+}
+
+// runOn runs the given command on conn, reporting the time spent waiting
+// for the connection to the queue observer.
+func (m *Mux) runOn(conn *muxConn, cmd string, args ...interface{}) (err error, r []reflect.Value) {
+	atomic.AddInt32(&conn.load, 1)
+	defer atomic.AddInt32(&conn.load, -1)
+
+	start := time.Now()
+	conn.client.mu.Lock()
+	if m.observer != nil {
+		m.observer(cmd, time.Since(start))
+	}
+	err, r = invokeMethodViaReflection(conn.client, cmd, args...)
+	conn.client.mu.Unlock()
+	return
+}
+
+// runCommand runs the given command on the least busy connection.
+func (m *Mux) runCommand(cmd string, args ...interface{}) (err error, r []reflect.Value) {
+	return m.runOn(m.pick(), cmd, args...)
+}
+
+// openScanner opens a scanner on the least busy connection and returns a
+// mux-wide scanner id, since ids issued by different gateways may collide.
+func (m *Mux) openScanner(cmd string, args ...interface{}) (ScannerID, error) {
+	conn := m.pick()
+	err, results := m.runOn(conn, cmd, args...)
+	if err != nil {
+		return ScannerID(0), err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastScanner++
+	m.scanners[m.lastScanner] = muxScanner{
+		conn: conn,
+		id:   ScannerID(results[0].Int()),
+	}
+	return m.lastScanner, nil
+}
+
+// lookupScanner finds the scanner with the given mux-wide id.
+func (m *Mux) lookupScanner(id ScannerID) (muxScanner, error) {
+	m.mu.Lock()
+	s, ok := m.scanners[id]
+	m.mu.Unlock()
+	if !ok {
+		return s, &IllegalArgument{Message: fmt.Sprintf("scanner %d not found", id)}
+	}
+	return s, nil
+}
+
+// runScanner runs the given scanner command on the connection owning id.
+func (m *Mux) runScanner(id ScannerID, cmd string, args ...interface{}) (err error, r []reflect.Value) {
+	s, err := m.lookupScanner(id)
+	if err != nil {
+		return err, nil
+	}
+	return m.runOn(s.conn, cmd, append([]interface{}{s.id}, args...)...)
+}
+
+// closeScanner closes the scanner with the given mux-wide id. The id is
+// kept after a transport error, as the gateway may not have closed the
+// scanner, so that the close can be retried.
+func (m *Mux) closeScanner(id ScannerID) error {
+	s, err := m.lookupScanner(id)
+	if err != nil {
+		return err
+	}
+	err, _ = m.runOn(s.conn, "ScannerClose", s.id)
+	if ErrorClass(err) != ErrClassTransport {
+		m.mu.Lock()
+		delete(m.scanners, id)
+		m.mu.Unlock()
+	}
+	return err
+}
+
+// AtomicIncrement runs HBase AtomicIncrement on one of the multiplexed connections.
+func (m *Mux) AtomicIncrement(tableName, row, column Text, value int64) (int64, error) {
+	err, results := m.runCommand("AtomicIncrement", tableName, row, column, value)
+	if err != nil {
+		return int64(0), err
+	}
+	return results[0].Int(), nil
+}
+
+// Compact runs HBase Compact on one of the multiplexed connections.
+func (m *Mux) Compact(tableNameOrRegionName Bytes) error {
+	err, _ := m.runCommand("Compact", tableNameOrRegionName)
+	return err
+}
+
+// CreateTable runs HBase CreateTable on one of the multiplexed connections.
+func (m *Mux) CreateTable(tableName Text, columnFamilies []*ColumnDescriptor) error {
+	err, _ := m.runCommand("CreateTable", tableName, columnFamilies)
+	return err
+}
+
+// DeleteAll runs HBase DeleteAll on one of the multiplexed connections.
+func (m *Mux) DeleteAll(tableName, row, column Text, attributes map[string]Text) error {
+	err, _ := m.runCommand("DeleteAll", tableName, row, column, attributes)
+	return err
+}
+
+// DeleteAllRow runs HBase DeleteAllRow on one of the multiplexed connections.
+func (m *Mux) DeleteAllRow(tableName, row Text, attributes map[string]Text) error {
+	err, _ := m.runCommand("DeleteAllRow", tableName, row, attributes)
+	return err
+}
+
+// DeleteAllRowTs runs HBase DeleteAllRowTs on one of the multiplexed connections.
+func (m *Mux) DeleteAllRowTs(tableName, row Text, timestamp int64, attributes map[string]Text) error {
+	err, _ := m.runCommand("DeleteAllRowTs", tableName, row, timestamp, attributes)
+	return err
+}
+
+// DeleteAllTs runs HBase DeleteAllTs on one of the multiplexed connections.
+func (m *Mux) DeleteAllTs(tableName, row, column Text, timestamp int64, attributes map[string]Text) error {
+	err, _ := m.runCommand("DeleteAllTs", tableName, row, column, timestamp, attributes)
+	return err
+}
+
+// DeleteTable runs HBase DeleteTable on one of the multiplexed connections.
+func (m *Mux) DeleteTable(tableName Text) error {
+	err, _ := m.runCommand("DeleteTable", tableName)
+	return err
+}
+
+// DisableTable runs HBase DisableTable on one of the multiplexed connections.
+func (m *Mux) DisableTable(tableName Bytes) error {
+	err, _ := m.runCommand("DisableTable", tableName)
+	return err
+}
+
+// EnableTable runs HBase EnableTable on one of the multiplexed connections.
+func (m *Mux) EnableTable(tableName Bytes) error {
+	err, _ := m.runCommand("EnableTable", tableName)
+	return err
+}
+
+// Get runs HBase Get on one of the multiplexed connections.
+func (m *Mux) Get(tableName, row, column Text, attributes map[string]Text) ([]*TCell, error) {
+	err, results := m.runCommand("Get", tableName, row, column, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TCell), nil
+}
+
+// GetColumnDescriptors runs HBase GetColumnDescriptors on one of the multiplexed connections.
+func (m *Mux) GetColumnDescriptors(tableName Text) (map[string]*ColumnDescriptor, error) {
+	err, results := m.runCommand("GetColumnDescriptors", tableName)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().(map[string]*ColumnDescriptor), nil
+}
+
+// GetRegionInfo runs HBase GetRegionInfo on one of the multiplexed connections.
+func (m *Mux) GetRegionInfo(row Text) (*TRegionInfo, error) {
+	err, results := m.runCommand("GetRegionInfo", row)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().(*TRegionInfo), nil
+}
+
+// GetRow runs HBase GetRow on one of the multiplexed connections.
+func (m *Mux) GetRow(tableName, row Text, attributes map[string]Text) ([]*TRowResult_, error) {
+	err, results := m.runCommand("GetRow", tableName, row, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// GetRowOrBefore runs HBase GetRowOrBefore on one of the multiplexed connections.
+func (m *Mux) GetRowOrBefore(tableName, row Text, family Text) ([]*TCell, error) {
+	err, results := m.runCommand("GetRowOrBefore", tableName, row, family)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TCell), nil
+}
+
+// GetRowTs runs HBase GetRowTs on one of the multiplexed connections.
+func (m *Mux) GetRowTs(tableName, row Text, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	err, results := m.runCommand("GetRowTs", tableName, row, timestamp, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// GetRowWithColumns runs HBase GetRowWithColumns on one of the multiplexed connections.
+func (m *Mux) GetRowWithColumns(tableName, row Text, columns [][]byte, attributes map[string]Text) ([]*TRowResult_, error) {
+	err, results := m.runCommand("GetRowWithColumns", tableName, row, columns, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// GetRowWithColumnsTs runs HBase GetRowWithColumnsTs on one of the multiplexed connections.
+func (m *Mux) GetRowWithColumnsTs(tableName, row Text, columns [][]byte, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	err, results := m.runCommand("GetRowWithColumnsTs", tableName, row, columns, timestamp, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// GetRows runs HBase GetRows on one of the multiplexed connections.
+func (m *Mux) GetRows(tableName Text, rows [][]byte, attributes map[string]Text) ([]*TRowResult_, error) {
+	err, results := m.runCommand("GetRows", tableName, rows, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// GetRowsTs runs HBase GetRowsTs on one of the multiplexed connections.
+func (m *Mux) GetRowsTs(tableName Text, rows [][]byte, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	err, results := m.runCommand("GetRowsTs", tableName, rows, timestamp, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// GetRowsWithColumns runs HBase GetRowsWithColumns on one of the multiplexed connections.
+func (m *Mux) GetRowsWithColumns(tableName Text, rows [][]byte, columns [][]byte, attributes map[string]Text) ([]*TRowResult_, error) {
+	err, results := m.runCommand("GetRowsWithColumns", tableName, rows, columns, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// GetRowsWithColumnsTs runs HBase GetRowsWithColumnsTs on one of the multiplexed connections.
+func (m *Mux) GetRowsWithColumnsTs(tableName Text, rows [][]byte, columns [][]byte, timestamp int64, attributes map[string]Text) ([]*TRowResult_, error) {
+	err, results := m.runCommand("GetRowsWithColumnsTs", tableName, rows, columns, timestamp, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// GetTableNames runs HBase GetTableNames on one of the multiplexed connections.
+func (m *Mux) GetTableNames() ([][]byte, error) {
+	err, results := m.runCommand("GetTableNames")
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([][]byte), nil
+}
+
+// GetTableRegions runs HBase GetTableRegions on one of the multiplexed connections.
+func (m *Mux) GetTableRegions(tableName Text) ([]*TRegionInfo, error) {
+	err, results := m.runCommand("GetTableRegions", tableName)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRegionInfo), nil
+}
+
+// GetVer runs HBase GetVer on one of the multiplexed connections.
+func (m *Mux) GetVer(tableName, row, column Text, numVersions int32, attributes map[string]Text) ([]*TCell, error) {
+	err, results := m.runCommand("GetVer", tableName, row, column, numVersions, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TCell), nil
+}
+
+// GetVerTs runs HBase GetVerTs on one of the multiplexed connections.
+func (m *Mux) GetVerTs(tableName, row, column Text, timestamp int64, numVersions int32, attributes map[string]Text) ([]*TCell, error) {
+	err, results := m.runCommand("GetVerTs", tableName, row, column, timestamp, numVersions, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TCell), nil
+}
+
+// Increment runs HBase Increment on one of the multiplexed connections.
+func (m *Mux) Increment(increment *TIncrement) error {
+	err, _ := m.runCommand("Increment", increment)
+	return err
+}
+
+// IncrementRows runs HBase IncrementRows on one of the multiplexed connections.
+func (m *Mux) IncrementRows(increments []*TIncrement) error {
+	err, _ := m.runCommand("IncrementRows", increments)
+	return err
+}
+
+// IsTableEnabled runs HBase IsTableEnabled on one of the multiplexed connections.
+func (m *Mux) IsTableEnabled(tableName Bytes) (bool, error) {
+	err, results := m.runCommand("IsTableEnabled", tableName)
+	if err != nil {
+		return false, err
+	}
+	return results[0].Bool(), nil
+}
+
+// MajorCompact runs HBase MajorCompact on one of the multiplexed connections.
+func (m *Mux) MajorCompact(tableNameOrRegionName Bytes) error {
+	err, _ := m.runCommand("MajorCompact", tableNameOrRegionName)
+	return err
+}
+
+// MutateRow runs HBase MutateRow on one of the multiplexed connections.
+func (m *Mux) MutateRow(tableName, row Text, mutations []*Mutation, attributes map[string]Text) error {
+	err, _ := m.runCommand("MutateRow", tableName, row, mutations, attributes)
+	return err
+}
+
+// MutateRowTs runs HBase MutateRowTs on one of the multiplexed connections.
+func (m *Mux) MutateRowTs(tableName, row Text, mutations []*Mutation, timestamp int64, attributes map[string]Text) error {
+	err, _ := m.runCommand("MutateRowTs", tableName, row, mutations, timestamp, attributes)
+	return err
+}
+
+// MutateRows runs HBase MutateRows on one of the multiplexed connections.
+func (m *Mux) MutateRows(tableName Text, rowBatches []*BatchMutation, attributes map[string]Text) error {
+	err, _ := m.runCommand("MutateRows", tableName, rowBatches, attributes)
+	return err
+}
+
+// MutateRowsTs runs HBase MutateRowsTs on one of the multiplexed connections.
+func (m *Mux) MutateRowsTs(tableName Text, rowBatches []*BatchMutation, timestamp int64, attributes map[string]Text) error {
+	err, _ := m.runCommand("MutateRowsTs", tableName, rowBatches, timestamp, attributes)
+	return err
+}
+
+// ScannerClose runs HBase ScannerClose on the connection that opened the scanner.
+func (m *Mux) ScannerClose(id ScannerID) error {
+	return m.closeScanner(id)
+}
+
+// ScannerGet runs HBase ScannerGet on the connection that opened the scanner.
+func (m *Mux) ScannerGet(id ScannerID) ([]*TRowResult_, error) {
+	err, results := m.runScanner(id, "ScannerGet")
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// ScannerGetList runs HBase ScannerGetList on the connection that opened the scanner.
+func (m *Mux) ScannerGetList(id ScannerID, nbRows int32) ([]*TRowResult_, error) {
+	err, results := m.runScanner(id, "ScannerGetList", nbRows)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TRowResult_), nil
+}
+
+// ScannerOpen runs HBase ScannerOpen on one of the multiplexed connections.
+func (m *Mux) ScannerOpen(tableName, startRow Text, columns [][]byte, attributes map[string]Text) (ScannerID, error) {
+	return m.openScanner("ScannerOpen", tableName, startRow, columns, attributes)
+}
+
+// ScannerOpenTs runs HBase ScannerOpenTs on one of the multiplexed connections.
+func (m *Mux) ScannerOpenTs(tableName, startRow Text, columns [][]byte, timestamp int64, attributes map[string]Text) (ScannerID, error) {
+	return m.openScanner("ScannerOpenTs", tableName, startRow, columns, timestamp, attributes)
+}
+
+// ScannerOpenWithPrefix runs HBase ScannerOpenWithPrefix on one of the multiplexed connections.
+func (m *Mux) ScannerOpenWithPrefix(tableName, startAndPrefix Text, columns [][]byte, attributes map[string]Text) (ScannerID, error) {
+	return m.openScanner("ScannerOpenWithPrefix", tableName, startAndPrefix, columns, attributes)
+}
+
+// ScannerOpenWithScan runs HBase ScannerOpenWithScan on one of the multiplexed connections.
+func (m *Mux) ScannerOpenWithScan(tableName Text, scan *TScan, attributes map[string]Text) (ScannerID, error) {
+	return m.openScanner("ScannerOpenWithScan", tableName, scan, attributes)
+}
+
+// ScannerOpenWithStop runs HBase ScannerOpenWithStop on one of the multiplexed connections.
+func (m *Mux) ScannerOpenWithStop(tableName, startRow, stopRow Text, columns [][]byte, attributes map[string]Text) (ScannerID, error) {
+	return m.openScanner("ScannerOpenWithStop", tableName, startRow, stopRow, columns, attributes)
+}
+
+// ScannerOpenWithStopTs runs HBase ScannerOpenWithStopTs on one of the multiplexed connections.
+func (m *Mux) ScannerOpenWithStopTs(tableName, startRow, stopRow Text, columns [][]byte, timestamp int64, attributes map[string]Text) (ScannerID, error) {
+	return m.openScanner("ScannerOpenWithStopTs", tableName, startRow, stopRow, columns, timestamp, attributes)
+}
+
+// Append runs HBase Append on one of the multiplexed connections.
+func (m *Mux) Append(append *TAppend) (r []*TCell, err error) {
+	err, results := m.runCommand("Append", append)
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Interface().([]*TCell), nil
+}
+
+// CheckAndPut runs HBase CheckAndPut on one of the multiplexed connections.
+func (m *Mux) CheckAndPut(tableName Text, row Text, column Text, value Text, mput *Mutation, attributes map[string]Text) (r bool, err error) {
+	err, results := m.runCommand("CheckAndPut", tableName, row, column, value, mput, attributes)
+	if err != nil {
+		return false, err
+	}
+	return results[0].Bool(), nil
+}
@@ -0,0 +1,134 @@
+package hbase
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+var _ Hbase = (*Mux)(nil)
+
+func TestMux(t *testing.T) {
+	mockServer := &MockHbase{}
+	mockServer.On("IsTableEnabled", Bytes("existTable")).Return(true, nil)
+	mockServer.On("ScannerOpenWithScan", Text("table"), mock.Anything, mock.Anything).
+		Return(ScannerID(1), nil)
+	mockServer.On("ScannerGetList", ScannerID(1), int32(10)).
+		Return([]*TRowResult_{{Row: Text("row")}}, nil)
+	mockServer.On("ScannerClose", ScannerID(1)).Return(nil)
+
+	srv, err := NewHbaseServer(mockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	var queued int
+	var mu sync.Mutex
+	mux, err := NewMux(ThriftClientFactory(fmt.Sprintf("127.0.0.1:%d", srv.Port)), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mux.Close()
+	mux.SetQueueObserver(func(cmd string, wait time.Duration) {
+		mu.Lock()
+		queued++
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := mux.IsTableEnabled(Bytes("existTable")); err != nil || !ok {
+				t.Errorf("unexpected result: %v, %v", ok, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if queued != 8 {
+		t.Fatalf("expected 8 queue observations, got %d", queued)
+	}
+
+	// both gateways hand out the same scanner id, mux ids must not collide
+	first, err := mux.ScannerOpenWithScan(Text("table"), &TScan{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := mux.ScannerOpenWithScan(Text("table"), &TScan{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatalf("scanner ids collide: %d", first)
+	}
+	rows, err := mux.ScannerGetList(second, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || string(rows[0].Row) != "row" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	if err := mux.ScannerClose(second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mux.ScannerGetList(second, 10); err == nil {
+		t.Fatalf("expected error on closed scanner")
+	}
+}
+
+func TestMuxScannerCloseError(t *testing.T) {
+	mockServer := &MockHbase{}
+	mockServer.On("ScannerOpenWithScan", Text("table"), mock.Anything, mock.Anything).
+		Return(ScannerID(1), nil)
+	mockServer.On("ScannerClose", ScannerID(1)).Return(nil)
+	srv, err := NewHbaseServer(mockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	faults := NewFaultInjector(FaultRule{Method: "ScannerClose", Truncate: true})
+	proxy, err := NewFaultProxy(fmt.Sprintf("127.0.0.1:%d", srv.Port), faults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+	mux, err := NewMux(ThriftClientFactory(proxy.Addr), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mux.Close()
+
+	id, err := mux.ScannerOpenWithScan(Text("table"), &TScan{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the scanner may still be open after a transport error
+	if err := mux.ScannerClose(id); ErrorClass(err) != ErrClassTransport {
+		t.Fatalf("expected a transport error, got %v", err)
+	}
+	if _, err := mux.lookupScanner(id); err != nil {
+		t.Fatalf("scanner forgotten after a transport error: %v", err)
+	}
+
+	// errors of the gateway close the scanner
+	faults.SetRules(FaultRule{Method: "ScannerClose", Err: &IOError{Message: "injected"}})
+	mux, err = NewMux(ThriftClientFactory(proxy.Addr), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mux.Close()
+	if id, err = mux.ScannerOpenWithScan(Text("table"), &TScan{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := mux.ScannerClose(id); ErrorClass(err) != ErrClassIO {
+		t.Fatalf("expected an io error, got %v", err)
+	}
+	if _, err := mux.lookupScanner(id); err == nil {
+		t.Fatal("scanner kept after a gateway error")
+	}
+}
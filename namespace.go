@@ -0,0 +1,256 @@
+package hbase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// namespace isolates a tenant in the tables starting with its prefix
+type namespace struct {
+	prefix []byte
+	// sep is the last byte of prefix, which tenant table names may not
+	// contain so that tenants with overlapping prefixes stay apart
+	sep byte
+
+	// mu guards scanners, the scanners opened in the namespace
+	mu       sync.Mutex
+	scanners map[ScannerID]bool
+}
+
+// table returns the physical name of a tenant table.
+func (n *namespace) table(name []byte) ([]byte, error) {
+	if len(name) == 0 || bytes.IndexAny(name, ":,") >= 0 || bytes.IndexByte(name, n.sep) >= 0 {
+		return nil, &IllegalArgument{Message: fmt.Sprintf("table %q is outside of namespace %q", name, n.prefix)}
+	}
+	return append(append([]byte(nil), n.prefix...), name...), nil
+}
+
+// region returns the physical name of a tenant region name or meta row,
+// which start with the table name followed by a comma.
+func (n *namespace) region(name []byte) ([]byte, error) {
+	i := bytes.IndexByte(name, ',')
+	if i < 0 {
+		return n.table(name)
+	}
+	table, err := n.table(name[:i])
+	if err != nil {
+		return nil, err
+	}
+	return append(table, name[i:]...), nil
+}
+
+// strip returns the tenant name of a physical table or region name. Names
+// whose table part contains the separator belong to a tenant with a longer
+// prefix, e.g. "team_a_users" to "team_a_" rather than "team_".
+func (n *namespace) strip(name []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(name, n.prefix) {
+		return nil, false
+	}
+	name = name[len(n.prefix):]
+	table := name
+	if i := bytes.IndexByte(name, ','); i >= 0 {
+		table = name[:i]
+	}
+	if len(table) == 0 || bytes.IndexByte(table, ':') >= 0 || bytes.IndexByte(table, n.sep) >= 0 {
+		return nil, false
+	}
+	return name, true
+}
+
+// checkScanner rejects scanner calls on scanners opened outside of the
+// namespace. Scanner ids are sequential, so they are easily guessed.
+func (n *namespace) checkScanner(args interface{}) error {
+	var id ScannerID
+	switch a := args.(type) {
+	case *ScannerGetArgs:
+		id = a.Id
+	case *ScannerGetListArgs:
+		id = a.Id
+	case *ScannerCloseArgs:
+		id = a.Id
+	default:
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.scanners[id] {
+		return &IllegalArgument{Message: fmt.Sprintf("scanner %d is outside of namespace %q", id, n.prefix)}
+	}
+	return nil
+}
+
+// trackScanner records the scanners opened by and forgets the scanners
+// closed by a call of method.
+func (n *namespace) trackScanner(method string, args, result interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if a, ok := args.(*ScannerCloseArgs); ok {
+		delete(n.scanners, a.Id)
+		return
+	}
+	success := resultSuccess(result)
+	if !strings.HasPrefix(method, "ScannerOpen") || !success.IsValid() {
+		return
+	}
+	if id, ok := success.Interface().(*ScannerID); ok && id != nil {
+		n.scanners[*id] = true
+	}
+}
+
+// copyArgs returns a shallow copy of a thrift args struct.
+func copyArgs(args interface{}) reflect.Value {
+	v := reflect.ValueOf(args)
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	return c
+}
+
+// rewriteArgs returns a copy of args addressing the physical tables.
+func (n *namespace) rewriteArgs(args interface{}) (interface{}, error) {
+	switch a := args.(type) {
+	case *CompactArgs:
+		name, err := n.region(a.TableNameOrRegionName)
+		return &CompactArgs{TableNameOrRegionName: name}, err
+	case *MajorCompactArgs:
+		name, err := n.region(a.TableNameOrRegionName)
+		return &MajorCompactArgs{TableNameOrRegionName: name}, err
+	case *GetRegionInfoArgs:
+		row, err := n.region(a.Row)
+		return &GetRegionInfoArgs{Row: row}, err
+	case *IncrementArgs:
+		if a.Increment == nil {
+			return args, nil
+		}
+		inc := *a.Increment
+		table, err := n.table(inc.Table)
+		inc.Table = table
+		return &IncrementArgs{Increment: &inc}, err
+	case *IncrementRowsArgs:
+		c := &IncrementRowsArgs{Increments: make([]*TIncrement, len(a.Increments))}
+		for i, inc := range a.Increments {
+			copied := *inc
+			table, err := n.table(inc.Table)
+			if err != nil {
+				return nil, err
+			}
+			copied.Table = table
+			c.Increments[i] = &copied
+		}
+		return c, nil
+	case *AppendArgs:
+		if a.Append == nil {
+			return args, nil
+		}
+		app := *a.Append
+		table, err := n.table(app.Table)
+		app.Table = table
+		return &AppendArgs{Append: &app}, err
+	}
+	if f := argsField(args, "TableName"); f.IsValid() {
+		table, err := n.table(f.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		c := copyArgs(args)
+		c.Elem().FieldByName("TableName").SetBytes(table)
+		return c.Interface(), nil
+	}
+	return args, nil
+}
+
+// stripRegion returns a copy of a region whose name is stripped of the
+// prefix, or an error if the region is outside of the namespace.
+func (n *namespace) stripRegion(r *TRegionInfo) (*TRegionInfo, error) {
+	if r == nil {
+		return nil, nil
+	}
+	name, ok := n.strip(r.Name)
+	if !ok {
+		return nil, &IllegalArgument{Message: fmt.Sprintf("region %q is outside of namespace %q", r.Name, n.prefix)}
+	}
+	c := *r
+	c.Name = name
+	return &c, nil
+}
+
+// rewriteResult rewrites the physical table and region names of a result.
+func (n *namespace) rewriteResult(result interface{}) error {
+	switch r := result.(type) {
+	case *GetTableNamesResult:
+		var names [][]byte
+		for _, name := range r.Success {
+			if table, ok := n.strip(name); ok {
+				names = append(names, table)
+			}
+		}
+		r.Success = names
+	case *GetTableRegionsResult:
+		for i, region := range r.Success {
+			stripped, err := n.stripRegion(region)
+			if err != nil {
+				return err
+			}
+			r.Success[i] = stripped
+		}
+	case *GetRegionInfoResult:
+		stripped, err := n.stripRegion(r.Success)
+		if err != nil {
+			return err
+		}
+		r.Success = stripped
+	}
+	return nil
+}
+
+// NamespaceInterceptor confines calls to the tables of a tenant: table
+// names are prefixed with prefix, either a table-name prefix such as
+// "team_" or an HBase namespace such as "team:". GetTableNames only lists
+// the tenant tables and region names are returned without the prefix.
+// Table and region names which would address another namespace, i.e.
+// containing a colon, are rejected, and so are scanner calls on scanners
+// the interceptor did not open.
+//
+// The last byte of prefix is its separator and must not be a letter or a
+// digit. Tenant table names may not contain it, so that tenants whose
+// prefixes overlap stay apart: with "team_" and "team_a_", "team_a_users"
+// belongs to the latter and "team_" cannot name a table "a_users".
+func NamespaceInterceptor(prefix string) (Interceptor, error) {
+	if err := validNamespacePrefix(prefix); err != nil {
+		return nil, err
+	}
+	n := &namespace{prefix: []byte(prefix), sep: prefix[len(prefix)-1], scanners: map[ScannerID]bool{}}
+	return func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		if err := n.checkScanner(args); err != nil {
+			return err
+		}
+		args, err := n.rewriteArgs(args)
+		if err != nil {
+			return err
+		}
+		err = invoker(ctx, method, args, result)
+		n.trackScanner(method, args, result)
+		if err != nil {
+			return err
+		}
+		return n.rewriteResult(result)
+	}, nil
+}
+
+// validNamespacePrefix checks a prefix of NamespaceInterceptor.
+func validNamespacePrefix(prefix string) error {
+	if len(prefix) < 2 || strings.Contains(prefix, ",") {
+		return fmt.Errorf("invalid namespace prefix %q", prefix)
+	}
+	if i := strings.IndexByte(prefix, ':'); i >= 0 && i != len(prefix)-1 {
+		return fmt.Errorf("namespace prefix %q has a colon before its end", prefix)
+	}
+	sep := prefix[len(prefix)-1]
+	if 'a' <= sep && sep <= 'z' || 'A' <= sep && sep <= 'Z' || '0' <= sep && sep <= '9' {
+		return fmt.Errorf("namespace prefix %q does not end with a separator", prefix)
+	}
+	return nil
+}
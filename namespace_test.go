@@ -0,0 +1,106 @@
+package hbase
+
+import (
+	"testing"
+)
+
+func TestNamespaceInterceptor(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("team:users", "m")
+	m.createTable("other:users")
+	m.put("team:users", "row", "cf:q", "team", 0)
+	m.put("other:users", "row", "cf:q", "other", 0)
+	h := Intercept(m, mustNamespace(t, "team:"))
+
+	names, err := h.GetTableNames()
+	if err != nil || len(names) != 1 || string(names[0]) != "users" {
+		t.Fatalf("unexpected tables %q: %v", names, err)
+	}
+	rows, err := h.GetRow(Text("users"), Text("row"), nil)
+	if err != nil || string(rows[0].Columns["cf:q"].Value) != "team" {
+		t.Fatalf("unexpected rows %v: %v", rows, err)
+	}
+	batches := []*BatchMutation{{Row: Text("new"), Mutations: []*Mutation{{Column: Text("cf:q"), Value: Text("v")}}}}
+	args := &MutateRowsArgs{TableName: Text("users"), RowBatches: batches}
+	if err := h.MutateRows(args.TableName, args.RowBatches, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.tables["team:users"].rows["new"]; !ok || string(args.TableName) != "users" {
+		t.Fatal("mutation not written to the namespaced table")
+	}
+	regions, err := h.GetTableRegions(Text("users"))
+	if err != nil || len(regions) != 2 || string(regions[1].Name) != "users,m" {
+		t.Fatalf("unexpected regions %v: %v", regions, err)
+	}
+
+	for _, table := range []string{"other:users", "", "users,x"} {
+		if _, err := h.GetRow(Text(table), Text("row"), nil); err == nil {
+			t.Fatalf("expected table %q to be rejected", table)
+		}
+	}
+	if err := h.Compact(Bytes("other:users,,1")); err == nil {
+		t.Fatal("expected a compaction outside of the namespace to be rejected")
+	}
+
+	// another tenant cannot use or close the scanners of this one
+	id, err := h.ScannerOpenWithScan(Text("users"), NewTScan(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := Intercept(m, mustNamespace(t, "other:"))
+	if _, err := other.ScannerGetList(id, 10); err == nil {
+		t.Fatal("expected a scanner of another namespace to be rejected")
+	}
+	if err := other.ScannerClose(id); err == nil {
+		t.Fatal("expected closing a scanner of another namespace to be rejected")
+	}
+	if rows, err := h.ScannerGetList(id, 10); err != nil || len(rows) != 2 {
+		t.Fatalf("unexpected scanned rows %v: %v", rows, err)
+	}
+	if err := h.ScannerClose(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.ScannerGetList(id, 10); err == nil {
+		t.Fatal("expected a closed scanner to be rejected")
+	}
+}
+
+func mustNamespace(t *testing.T, prefix string) Interceptor {
+	t.Helper()
+	i, err := NamespaceInterceptor(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return i
+}
+
+func TestNamespaceInterceptorPrefix(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("team_users")
+	m.createTable("team_a_users")
+	m.put("team_a_users", "row", "cf:q", "a", 0)
+	team := Intercept(m, mustNamespace(t, "team_"))
+	teamA := Intercept(m, mustNamespace(t, "team_a_"))
+
+	for _, c := range []struct {
+		h    *InterceptedHbase
+		want string
+	}{{team, "users"}, {teamA, "users"}} {
+		names, err := c.h.GetTableNames()
+		if err != nil || len(names) != 1 || string(names[0]) != c.want {
+			t.Fatalf("unexpected tables %q: %v", names, err)
+		}
+	}
+	if _, err := team.GetRow(Text("a_users"), Text("row"), nil); err == nil {
+		t.Fatal("expected a table of an overlapping prefix to be rejected")
+	}
+	if rows, err := teamA.GetRow(Text("users"), Text("row"), nil); err != nil || len(rows) != 1 {
+		t.Fatalf("unexpected rows %v: %v", rows, err)
+	}
+
+	for _, prefix := range []string{"", "_", "team", ":", "a:b:", "a,b:", "team:x_"} {
+		if _, err := NamespaceInterceptor(prefix); err == nil {
+			t.Errorf("expected prefix %q to be rejected", prefix)
+		}
+	}
+}
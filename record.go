@@ -0,0 +1,390 @@
+package hbase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// RecordFormat is the encoding of recorded calls
+type RecordFormat int
+
+const (
+	// RecordJSON encodes one call per line as JSON
+	RecordJSON RecordFormat = iota
+	// RecordBinary encodes calls back to back with the thrift binary protocol
+	RecordBinary
+)
+
+// CallRecord is a recorded Hbase call.
+type CallRecord struct {
+	Method string `json:"method"`
+	// Args is the thrift args struct of Method, e.g. *GetArgs for Get
+	Args interface{} `json:"args"`
+	// Result is the thrift result struct of Method, e.g. *GetResult for Get
+	Result interface{} `json:"result"`
+	// Error is the message of the returned error, if any
+	Error string `json:"error,omitempty"`
+	// ErrorClass is the class of the returned error, see ErrorClass
+	ErrorClass string `json:"errorClass,omitempty"`
+}
+
+// UnmarshalJSON decodes Args and Result into the thrift structs of Method.
+func (c *CallRecord) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Method     string          `json:"method"`
+		Args       json.RawMessage `json:"args"`
+		Result     json.RawMessage `json:"result"`
+		Error      string          `json:"error"`
+		ErrorClass string          `json:"errorClass"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	args, result, ok := NewCall(raw.Method)
+	if !ok {
+		return fmt.Errorf("unknown method %q", raw.Method)
+	}
+	if err := json.Unmarshal(raw.Args, args); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw.Result, result); err != nil {
+		return err
+	}
+	*c = CallRecord{
+		Method:     raw.Method,
+		Args:       args,
+		Result:     result,
+		Error:      raw.Error,
+		ErrorClass: raw.ErrorClass,
+	}
+	return nil
+}
+
+// Err rebuilds the error returned by the recorded call, keeping its class.
+func (c *CallRecord) Err() error {
+	if c.Error == "" && c.ErrorClass == "" {
+		return nil
+	}
+	switch c.ErrorClass {
+	case ErrClassIO:
+		return &IOError{Message: c.Error}
+	case ErrClassIllegalArgument:
+		return &IllegalArgument{Message: c.Error}
+	case ErrClassAlreadyExists:
+		return &AlreadyExists{Message: c.Error}
+	case ErrClassApplication:
+		return thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, c.Error)
+	}
+	return errors.New(c.Error)
+}
+
+// errorMessage returns the message of err as recorded in a CallRecord.
+func errorMessage(err error) string {
+	switch e := err.(type) {
+	case *IOError:
+		return e.Message
+	case *IllegalArgument:
+		return e.Message
+	case *AlreadyExists:
+		return e.Message
+	}
+	return err.Error()
+}
+
+// Recorder records every call passing its interceptor to a writer.
+type Recorder struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format RecordFormat
+	err    error
+}
+
+// NewRecorder creates a Recorder writing calls to w in the given format.
+func NewRecorder(w io.Writer, format RecordFormat) *Recorder {
+	return &Recorder{
+		w:      w,
+		format: format,
+	}
+}
+
+// Err returns the first error encountered while writing records. Failing
+// to record does not fail the recorded calls.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Interceptor returns an Interceptor which records every call.
+func (r *Recorder) Interceptor() Interceptor {
+	return func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		err := invoker(ctx, method, args, result)
+		rec := &CallRecord{
+			Method: method,
+			Args:   args,
+			Result: result,
+		}
+		if err != nil {
+			rec.Error = errorMessage(err)
+			rec.ErrorClass = ErrorClass(err)
+		}
+		r.write(rec)
+		return err
+	}
+}
+
+// write encodes rec and writes it out in a single write.
+func (r *Recorder) write(rec *CallRecord) {
+	var buf []byte
+	var err error
+	switch r.format {
+	case RecordBinary:
+		buf, err = encodeBinaryRecord(rec)
+	default:
+		buf, err = json.Marshal(rec)
+		buf = append(buf, '\n')
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		_, err = r.w.Write(buf)
+	}
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// encodeBinaryRecord encodes rec as a thrift message named after the
+// method, holding the args and result structs and the error.
+func encodeBinaryRecord(rec *CallRecord) ([]byte, error) {
+	buf := thrift.NewTMemoryBuffer()
+	oprot := thrift.NewTBinaryProtocolTransport(buf)
+	if err := oprot.WriteMessageBegin(rec.Method, thrift.CALL, 0); err != nil {
+		return nil, err
+	}
+	if err := rec.Args.(thrift.TStruct).Write(oprot); err != nil {
+		return nil, err
+	}
+	if err := rec.Result.(thrift.TStruct).Write(oprot); err != nil {
+		return nil, err
+	}
+	if err := oprot.WriteString(rec.ErrorClass); err != nil {
+		return nil, err
+	}
+	if err := oprot.WriteString(rec.Error); err != nil {
+		return nil, err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return nil, err
+	}
+	if err := oprot.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadRecords reads all calls recorded in the given format from r.
+func ReadRecords(r io.Reader, format RecordFormat) ([]*CallRecord, error) {
+	if format == RecordBinary {
+		return readBinaryRecords(r)
+	}
+	var records []*CallRecord
+	dec := json.NewDecoder(r)
+	for {
+		rec := &CallRecord{}
+		if err := dec.Decode(rec); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+func readBinaryRecords(r io.Reader) ([]*CallRecord, error) {
+	br := bufio.NewReader(r)
+	iprot := thrift.NewTBinaryProtocolTransport(thrift.NewStreamTransportR(br))
+	var records []*CallRecord
+	for {
+		if _, err := br.Peek(1); err == io.EOF {
+			return records, nil
+		}
+		method, _, _, err := iprot.ReadMessageBegin()
+		if err != nil {
+			return records, err
+		}
+		args, result, ok := NewCall(method)
+		if !ok {
+			return records, fmt.Errorf("unknown method %q", method)
+		}
+		rec := &CallRecord{
+			Method: method,
+			Args:   args,
+			Result: result,
+		}
+		if err := args.(thrift.TStruct).Read(iprot); err != nil {
+			return records, err
+		}
+		if err := result.(thrift.TStruct).Read(iprot); err != nil {
+			return records, err
+		}
+		if rec.ErrorClass, err = iprot.ReadString(); err != nil {
+			return records, err
+		}
+		if rec.Error, err = iprot.ReadString(); err != nil {
+			return records, err
+		}
+		if err := iprot.ReadMessageEnd(); err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// ReplayMode decides which recorded call answers a replayed call
+type ReplayMode int
+
+const (
+	// ReplayInOrder answers calls with the records in recorded order. A call
+	// whose method differs from the next record is not answered.
+	ReplayInOrder ReplayMode = iota
+	// ReplayMatchArgs answers calls with the first unused record of the same
+	// method and args. Once all matching records are used, the last one is
+	// reused.
+	ReplayMatchArgs
+)
+
+// Replayer answers calls with recorded ones, without any HBase.
+type Replayer struct {
+	// MatchAttributes makes ReplayMatchArgs compare call attributes too.
+	// They are ignored by default since they often carry per call values,
+	// e.g. trace ids.
+	MatchAttributes bool
+
+	mu         sync.Mutex
+	mode       ReplayMode
+	records    []*CallRecord
+	used       []bool
+	next       int
+	unrecorded []*CallRecord
+}
+
+// NewReplayer creates a Replayer answering calls with records.
+func NewReplayer(records []*CallRecord, mode ReplayMode) *Replayer {
+	return &Replayer{
+		mode:    mode,
+		records: records,
+		used:    make([]bool, len(records)),
+	}
+}
+
+// Hbase returns an Hbase answering all calls from the records.
+func (r *Replayer) Hbase() *InterceptedHbase {
+	return Intercept(nil, r.Interceptor())
+}
+
+// Interceptor returns an Interceptor answering calls from the records. It
+// never calls through to the wrapped Hbase.
+func (r *Replayer) Interceptor() Interceptor {
+	return func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		rec := r.find(method, args)
+		if rec == nil {
+			return fmt.Errorf("%s call was not recorded", method)
+		}
+		if success := resultSuccess(rec.Result); success.IsValid() {
+			resultSuccess(result).Set(success)
+		}
+		return rec.Err()
+	}
+}
+
+// Unrecorded returns the calls which were not found in the records.
+func (r *Replayer) Unrecorded() []*CallRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*CallRecord(nil), r.unrecorded...)
+}
+
+// Unused returns the records which did not answer any call.
+func (r *Replayer) Unused() []*CallRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []*CallRecord
+	for i, rec := range r.records {
+		if !r.used[i] {
+			unused = append(unused, rec)
+		}
+	}
+	return unused
+}
+
+// find returns the record answering a call, or nil.
+func (r *Replayer) find(method string, args interface{}) *CallRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := -1
+	switch r.mode {
+	case ReplayInOrder:
+		if r.next < len(r.records) && r.records[r.next].Method == method {
+			found = r.next
+			r.next++
+		}
+	case ReplayMatchArgs:
+		key, err := r.argsKey(args)
+		for i, rec := range r.records {
+			if err != nil || rec.Method != method {
+				continue
+			}
+			if recKey, err := r.argsKey(rec.Args); err != nil || !bytes.Equal(key, recKey) || !r.sameAttributes(args, rec.Args) {
+				continue
+			}
+			found = i
+			if !r.used[i] {
+				break
+			}
+		}
+	}
+	if found < 0 {
+		r.unrecorded = append(r.unrecorded, &CallRecord{Method: method, Args: args})
+		return nil
+	}
+	r.used[found] = true
+	return r.records[found]
+}
+
+// argsKey encodes args but their attributes with the thrift binary protocol
+// to compare them. Attributes are compared by sameAttributes since the
+// protocol writes maps in random order.
+func (r *Replayer) argsKey(args interface{}) ([]byte, error) {
+	if attributes, ok := AttributesOf(args); ok && attributes != nil {
+		SetAttributes(args, nil)
+		defer SetAttributes(args, attributes)
+	}
+	buf := thrift.NewTMemoryBuffer()
+	if err := args.(thrift.TStruct).Write(thrift.NewTBinaryProtocolTransport(buf)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sameAttributes reports whether two calls have the same attributes, or
+// true if attributes are not matched.
+func (r *Replayer) sameAttributes(a, b interface{}) bool {
+	if !r.MatchAttributes {
+		return true
+	}
+	x, _ := AttributesOf(a)
+	y, _ := AttributesOf(b)
+	return len(x) == 0 && len(y) == 0 || reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,93 @@
+package hbase
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	mockHbase := &MockHbase{}
+	mockHbase.On("GetRow", Text("table"), Text("a"), map[string]Text(nil)).
+		Return([]*TRowResult_{{Row: Text("a"), Columns: map[string]*TCell{"cf:q": {Value: Bytes("\x00\xff")}}}}, nil)
+	mockHbase.On("GetRow", Text("table"), Text("b"), map[string]Text(nil)).
+		Return([]*TRowResult_(nil), &IOError{Message: "boom"})
+	mockHbase.On("IsTableEnabled", Bytes("table")).Return(true, nil)
+
+	for _, format := range []RecordFormat{RecordJSON, RecordBinary} {
+		var buf bytes.Buffer
+		recorder := NewRecorder(&buf, format)
+		h := Intercept(mockHbase, recorder.Interceptor())
+		h.GetRow(Text("table"), Text("a"), nil)
+		h.GetRow(Text("table"), Text("b"), nil)
+		h.IsTableEnabled(Bytes("table"))
+		if err := recorder.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		records, err := ReadRecords(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 3 {
+			t.Fatalf("expected 3 records, got %d", len(records))
+		}
+
+		// replay by args, in a different order than recorded
+		replayer := NewReplayer(records, ReplayMatchArgs)
+		replay := replayer.Hbase()
+		if ok, err := replay.IsTableEnabled(Bytes("table")); err != nil || !ok {
+			t.Fatalf("unexpected result: %v, %v", ok, err)
+		}
+		if _, err := replay.GetRow(Text("table"), Text("b"), map[string]Text{"traceparent": Text("x")}); ErrorClass(err) != ErrClassIO {
+			t.Fatalf("expected io error, got %v", err)
+		}
+		if _, err := replay.GetRow(Text("table"), Text("c"), nil); err == nil {
+			t.Fatal("expected error on unrecorded call")
+		}
+		if unrecorded := replayer.Unrecorded(); len(unrecorded) != 1 || string(RowOf(unrecorded[0].Args)) != "c" {
+			t.Fatalf("unexpected unrecorded calls: %v", unrecorded)
+		}
+		if unused := replayer.Unused(); len(unused) != 1 || string(RowOf(unused[0].Args)) != "a" {
+			t.Fatalf("unexpected unused records: %v", unused)
+		}
+
+		// replay in order
+		replay = NewReplayer(records, ReplayInOrder).Hbase()
+		rows, err := replay.GetRow(Text("table"), Text("a"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 || !bytes.Equal(rows[0].Columns["cf:q"].Value, Bytes("\x00\xff")) {
+			t.Fatalf("unexpected rows: %v", rows)
+		}
+		if _, err := replay.IsTableEnabled(Bytes("table")); err == nil {
+			t.Fatal("expected error on out of order call")
+		}
+	}
+}
+
+func TestReplayMatchAttributes(t *testing.T) {
+	attributes := map[string]Text{"a": Text("1"), "b": Text("2"), "c": Text("3"), "d": Text("4")}
+	records := []*CallRecord{{
+		Method: "GetRow",
+		Args:   &GetRowArgs{TableName: Text("table"), Row: Text("a"), Attributes: attributes},
+		Result: &GetRowResult{Success: []*TRowResult_{{Row: Text("a")}}},
+	}}
+	replayer := NewReplayer(records, ReplayMatchArgs)
+	replayer.MatchAttributes = true
+	replay := replayer.Hbase()
+
+	// maps are encoded in random order, so repeat to catch order dependence
+	for i := 0; i < 20; i++ {
+		copied := map[string]Text{}
+		for k, v := range attributes {
+			copied[k] = v
+		}
+		if _, err := replay.GetRow(Text("table"), Text("a"), copied); err != nil {
+			t.Fatalf("call %d not matched: %v", i, err)
+		}
+	}
+	if _, err := replay.GetRow(Text("table"), Text("a"), map[string]Text{"a": Text("other")}); err == nil {
+		t.Fatal("expected calls with other attributes not to match")
+	}
+}
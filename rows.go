@@ -0,0 +1,249 @@
+package hbase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// defaultScanBatch is the default number of rows fetched per ScannerGetList
+const defaultScanBatch = 100
+
+// sortedColumns returns the cells of r ordered by column name. It uses
+// SortedColumns when the scan asked for it and sorts Columns otherwise.
+func sortedColumns(r *TRowResult_) []*TColumn {
+	if len(r.SortedColumns) > 0 {
+		return r.SortedColumns
+	}
+	names := make([]string, 0, len(r.Columns))
+	for name := range r.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	columns := make([]*TColumn, len(names))
+	for i, name := range names {
+		columns[i] = &TColumn{
+			ColumnName: Text(name),
+			Cell:       r.Columns[name],
+		}
+	}
+	return columns
+}
+
+// prefixStopRow returns the smallest row greater than all rows starting
+// with prefix, or nil if there is none.
+func prefixStopRow(prefix []byte) Text {
+	stop := append(Text(nil), prefix...)
+	for i := len(stop) - 1; i >= 0; i-- {
+		if stop[i] < 0xff {
+			stop[i]++
+			return stop[:i+1]
+		}
+	}
+	return nil
+}
+
+// scanRows scans table with scan, fetching batch rows at a time, and calls
+// fn for every row until fn returns an error.
+func scanRows(h Hbase, table Text, scan *TScan, batch int32, fn func(*TRowResult_) error) error {
+	if batch <= 0 {
+		batch = defaultScanBatch
+	}
+	id, err := h.ScannerOpenWithScan(table, scan, nil)
+	if err != nil {
+		return err
+	}
+	defer h.ScannerClose(id)
+	for {
+		rows, err := h.ScannerGetList(id, batch)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		for _, r := range rows {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// keyRange is the range of rows [start, stop), an empty bound being
+// unbounded
+type keyRange struct {
+	start, stop Text
+}
+
+// regionRanges splits [start, stop) at the region boundaries of table so
+// that each range can be scanned by its own worker.
+func regionRanges(h Hbase, table, start, stop Text) ([]keyRange, error) {
+	regions, err := h.GetTableRegions(table)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return bytes.Compare(regions[i].StartKey, regions[j].StartKey) < 0
+	})
+	var ranges []keyRange
+	for _, region := range regions {
+		r := keyRange{start: region.StartKey, stop: region.EndKey}
+		if bytes.Compare(r.start, start) < 0 {
+			r.start = start
+		}
+		if len(stop) > 0 && (len(r.stop) == 0 || bytes.Compare(stop, r.stop) < 0) {
+			r.stop = stop
+		}
+		if len(r.stop) > 0 && bytes.Compare(r.start, r.stop) >= 0 {
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+	if len(regions) == 0 {
+		ranges = []keyRange{{start: start, stop: stop}}
+	}
+	return ranges, nil
+}
+
+// SplitColumn splits a family:qualifier column name. A name without colon
+// is a family.
+func SplitColumn(column []byte) (family, qualifier string) {
+	if i := bytes.IndexByte(column, ':'); i >= 0 {
+		return string(column[:i]), string(column[i+1:])
+	}
+	return string(column), ""
+}
+
+// RowView is a family -> qualifier -> versions view of a row. Versions are
+// ordered latest first.
+type RowView struct {
+	Row      Text
+	Families map[string]map[string][]*TCell
+}
+
+// NewRowView creates the view of a row result, which holds the latest
+// version of its cells. More versions can be merged with AddVersions.
+func NewRowView(r *TRowResult_) *RowView {
+	v := &RowView{Row: r.Row, Families: map[string]map[string][]*TCell{}}
+	for _, col := range sortedColumns(r) {
+		v.AddVersions(col.ColumnName, []*TCell{col.Cell})
+	}
+	return v
+}
+
+// AddVersions merges versions of a column, e.g. as returned by GetVer,
+// into the view. Versions with the timestamp of a known version replace
+// it.
+func (v *RowView) AddVersions(column []byte, cells []*TCell) {
+	family, qualifier := SplitColumn(column)
+	qualifiers := v.Families[family]
+	if qualifiers == nil {
+		qualifiers = map[string][]*TCell{}
+		v.Families[family] = qualifiers
+	}
+	versions := qualifiers[qualifier]
+	for _, c := range cells {
+		if c == nil {
+			continue
+		}
+		i := sort.Search(len(versions), func(i int) bool { return versions[i].Timestamp <= c.Timestamp })
+		if i < len(versions) && versions[i].Timestamp == c.Timestamp {
+			versions[i] = c
+			continue
+		}
+		versions = append(versions, nil)
+		copy(versions[i+1:], versions[i:])
+		versions[i] = c
+	}
+	qualifiers[qualifier] = versions
+}
+
+// Versions returns the versions of family:qualifier, latest first.
+func (v *RowView) Versions(family, qualifier string) []*TCell {
+	return v.Families[family][qualifier]
+}
+
+// Latest returns the latest version of family:qualifier.
+func (v *RowView) Latest(family, qualifier string) (*TCell, bool) {
+	versions := v.Versions(family, qualifier)
+	if len(versions) == 0 {
+		return nil, false
+	}
+	return versions[0], true
+}
+
+// GetString returns the latest value of family:qualifier as a string.
+func (v *RowView) GetString(family, qualifier string) (string, bool) {
+	c, ok := v.Latest(family, qualifier)
+	if !ok {
+		return "", false
+	}
+	return string(c.Value), true
+}
+
+// GetInt64 returns the latest value of family:qualifier decoded as a
+// big-endian int64, the format of AtomicIncrement counters. ok is false if
+// the cell is missing or is not 8 bytes long.
+func (v *RowView) GetInt64(family, qualifier string) (int64, bool) {
+	c, ok := v.Latest(family, qualifier)
+	if !ok || len(c.Value) != 8 {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(c.Value)), true
+}
+
+// GetTime returns the latest value of family:qualifier decoded as
+// milliseconds since the epoch in big-endian int64, like GetInt64. ok is
+// false if the cell is missing or is not 8 bytes long.
+func (v *RowView) GetTime(family, qualifier string) (time.Time, bool) {
+	ms, ok := v.GetInt64(family, qualifier)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// Timestamp returns the time the latest version of family:qualifier was
+// written, from its millisecond timestamp.
+func (v *RowView) Timestamp(family, qualifier string) (time.Time, bool) {
+	c, ok := v.Latest(family, qualifier)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(c.Timestamp), true
+}
+
+// Range calls fn for every column in HBase order, i.e. by family then
+// qualifier in byte order, with the versions of the column, until fn
+// returns false.
+func (v *RowView) Range(fn func(family, qualifier string, versions []*TCell) bool) {
+	families := make([]string, 0, len(v.Families))
+	for family := range v.Families {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	for _, family := range families {
+		qualifiers := make([]string, 0, len(v.Families[family]))
+		for qualifier := range v.Families[family] {
+			qualifiers = append(qualifiers, qualifier)
+		}
+		sort.Strings(qualifiers)
+		for _, qualifier := range qualifiers {
+			if !fn(family, qualifier, v.Families[family][qualifier]) {
+				return
+			}
+		}
+	}
+}
+
+// Columns returns the family:qualifier names of the view in HBase order.
+func (v *RowView) Columns() []string {
+	var columns []string
+	v.Range(func(family, qualifier string, versions []*TCell) bool {
+		columns = append(columns, family+":"+qualifier)
+		return true
+	})
+	return columns
+}
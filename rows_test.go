@@ -0,0 +1,71 @@
+package hbase
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRowView(t *testing.T) {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, 42)
+	created := make([]byte, 8)
+	binary.BigEndian.PutUint64(created, 1600000000000)
+	v := NewRowView(&TRowResult_{
+		Row: Text("row"),
+		Columns: map[string]*TCell{
+			"cf:b":     {Value: Bytes("b"), Timestamp: 20},
+			"cf:a":     {Value: Bytes("a"), Timestamp: 1500000000000},
+			"count:n":  {Value: counter, Timestamp: 5},
+			"cf:t":     {Value: created, Timestamp: 7},
+			"cf:\xff":  {Value: Bytes("high"), Timestamp: 1},
+			"Upper:a:": {Value: Bytes("colon"), Timestamp: 1},
+		},
+	})
+
+	if columns := v.Columns(); !reflect.DeepEqual(columns, []string{"Upper:a:", "cf:a", "cf:b", "cf:t", "cf:\xff", "count:n"}) {
+		t.Fatalf("unexpected column order %q", columns)
+	}
+	if s, ok := v.GetString("Upper", "a:"); !ok || s != "colon" {
+		t.Fatalf("unexpected string %q, %v", s, ok)
+	}
+	if n, ok := v.GetInt64("count", "n"); !ok || n != 42 {
+		t.Fatalf("unexpected counter %d, %v", n, ok)
+	}
+	if _, ok := v.GetInt64("cf", "a"); ok {
+		t.Fatal("expected a malformed counter")
+	}
+	if _, ok := v.GetString("cf", "missing"); ok {
+		t.Fatal("expected a missing value")
+	}
+	if tm, ok := v.GetTime("cf", "t"); !ok || !tm.Equal(time.Unix(1600000000, 0)) {
+		t.Fatalf("unexpected time %v", tm)
+	}
+	if _, ok := v.GetTime("cf", "a"); ok {
+		t.Fatal("expected a malformed time")
+	}
+	if tm, ok := v.Timestamp("cf", "a"); !ok || !tm.Equal(time.Unix(1500000000, 0)) {
+		t.Fatalf("unexpected timestamp %v", tm)
+	}
+
+	// merge two GetVer responses
+	v.AddVersions(Text("cf:b"), []*TCell{{Value: Bytes("b2"), Timestamp: 30}, {Value: Bytes("b1"), Timestamp: 10}})
+	v.AddVersions(Text("cf:b"), []*TCell{{Value: Bytes("b"), Timestamp: 20}, {Value: Bytes("b0"), Timestamp: 0}})
+	var values []string
+	for _, c := range v.Versions("cf", "b") {
+		values = append(values, string(c.Value))
+	}
+	if !reflect.DeepEqual(values, []string{"b2", "b", "b1", "b0"}) {
+		t.Fatalf("unexpected versions %q", values)
+	}
+
+	n := 0
+	v.Range(func(family, qualifier string, versions []*TCell) bool {
+		n++
+		return n < 2
+	})
+	if n != 2 {
+		t.Fatalf("range did not stop: %d", n)
+	}
+}
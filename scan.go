@@ -0,0 +1,135 @@
+package hbase
+
+import (
+	"context"
+	"iter"
+)
+
+// defaultGetRowsChunk is the default number of rows fetched per GetRows
+// call of GetRowsChunked
+const defaultGetRowsChunk = 1000
+
+// contextHbase returns the Hbase the calls of ctx are made on: h itself,
+// or for an InterceptedHbase a copy passing ctx to its interceptors. It
+// fails if ctx is already done.
+func contextHbase(ctx context.Context, h Hbase) (Hbase, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if ih, ok := h.(*InterceptedHbase); ok {
+		return ih.WithContext(ctx), nil
+	}
+	return h, nil
+}
+
+// Scan returns an iterator over the rows of table matched by scan:
+//
+//	for row, err := range hbase.Scan(ctx, conn, table, scan) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Rows are fetched scan.Caching at a time, 100 if unset. An error ends the
+// iteration after it is yielded, and ctx is checked before every row. The
+// scanner is closed when the iteration ends, including when the loop
+// breaks early.
+func Scan(ctx context.Context, h Hbase, table Text, scan *TScan) iter.Seq2[*TRowResult_, error] {
+	return func(yield func(*TRowResult_, error) bool) {
+		h, err := contextHbase(ctx, h)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		batch := scan.GetCaching()
+		if batch <= 0 {
+			batch = defaultScanBatch
+		}
+		c, err := openCursor(h, table, scan, batch)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer c.close()
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			row, err := c.peek()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if row == nil {
+				return
+			}
+			c.pop()
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Scan returns an iterator over the rows of table matched by scan, see
+// the package Scan.
+func (c *WrapConn) Scan(ctx context.Context, table Text, scan *TScan) iter.Seq2[*TRowResult_, error] {
+	return Scan(ctx, c, table, scan)
+}
+
+// Scan returns an iterator over the rows of table matched by scan, see
+// the package Scan.
+func (m *Mux) Scan(ctx context.Context, table Text, scan *TScan) iter.Seq2[*TRowResult_, error] {
+	return Scan(ctx, m, table, scan)
+}
+
+// Scan returns an iterator over the rows of table matched by scan, see
+// the package Scan. The interceptors receive ctx.
+func (c *InterceptedHbase) Scan(ctx context.Context, table Text, scan *TScan) iter.Seq2[*TRowResult_, error] {
+	return Scan(ctx, c, table, scan)
+}
+
+// GetRowsChunked returns an iterator over the rows of table with the given
+// keys, fetched with one GetRows call, or GetRowsWithColumns if columns is
+// not empty, per chunk of at most chunk keys (1000 if zero). Like GetRows,
+// it skips keys which have no row. An error ends the iteration after it is
+// yielded, and ctx is checked before every call.
+func GetRowsChunked(ctx context.Context, h Hbase, table Text, rows, columns [][]byte, chunk int) iter.Seq2[*TRowResult_, error] {
+	if chunk <= 0 {
+		chunk = defaultGetRowsChunk
+	}
+	return func(yield func(*TRowResult_, error) bool) {
+		h, err := contextHbase(ctx, h)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for start := 0; start < len(rows); start += chunk {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			end := start + chunk
+			if end > len(rows) {
+				end = len(rows)
+			}
+			var results []*TRowResult_
+			if len(columns) > 0 {
+				results, err = h.GetRowsWithColumns(table, rows[start:end], columns, nil)
+			} else {
+				results, err = h.GetRows(table, rows[start:end], nil)
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, r := range results {
+				if !yield(r, nil) {
+					return
+				}
+			}
+		}
+	}
+}
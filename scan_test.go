@@ -0,0 +1,71 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+	m := newMemHbase()
+	m.createTable("t")
+	for i := 0; i < 7; i++ {
+		m.put("t", fmt.Sprintf("row%d", i), "cf:a", "v", 0)
+	}
+	calls := map[string]int{}
+	h := Intercept(m, func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		calls[method]++
+		return invoker(ctx, method, args, result)
+	})
+
+	scan := NewTScan()
+	scan.StartRow = Text("row1")
+	caching := int32(2)
+	scan.Caching = &caching
+	var rows []string
+	for row, err := range h.Scan(ctx, Text("t"), scan) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, string(row.Row))
+		if len(rows) == 3 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(rows, []string{"row1", "row2", "row3"}) {
+		t.Fatalf("unexpected rows %q", rows)
+	}
+	if calls["ScannerGetList"] != 2 || calls["ScannerClose"] != 1 || len(m.scanners) != 0 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+
+	var errs []error
+	for _, err := range Scan(ctx, m, Text("missing"), NewTScan()) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || errs[0] == nil {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	keys := [][]byte{[]byte("row6"), []byte("none"), []byte("row0"), []byte("row3"), []byte("row5")}
+	rows = nil
+	for row, err := range GetRowsChunked(ctx, h, Text("t"), keys, nil, 2) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, string(row.Row))
+	}
+	if !reflect.DeepEqual(rows, []string{"row6", "row0", "row3", "row5"}) || calls["GetRows"] != 3 {
+		t.Fatalf("unexpected rows %q, calls %v", rows, calls)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	for row, err := range GetRowsChunked(cancelled, h, Text("t"), keys, nil, 2) {
+		if row != nil || err != context.Canceled {
+			t.Fatalf("unexpected row %v, error %v", row, err)
+		}
+	}
+}
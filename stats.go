@@ -0,0 +1,185 @@
+package hbase
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// countFilter is the filter string used to count rows: it returns the key
+// of the first cell of every row only
+const countFilter = "FirstKeyOnlyFilter() AND KeyOnlyFilter()"
+
+// sizeSampleSize is the number of value sizes kept to estimate percentiles
+const sizeSampleSize = 10000
+
+// StatsOptions configures TableStatistics.
+type StatsOptions struct {
+	// StartRow and StopRow restrict the statistics to a key range
+	StartRow Text
+	StopRow  Text
+	// Columns restricts the statistics to these families or
+	// family:qualifier columns
+	Columns [][]byte
+	// Full scans all cells to report family statistics. Otherwise rows
+	// are counted with key only filters.
+	Full bool
+	// MaxVersions, in full mode, counts up to this many versions of every
+	// cell with GetVer. Zero skips version counting, which costs a call per
+	// cell.
+	MaxVersions int32
+	// BatchSize is the number of rows fetched per call
+	BatchSize int32
+}
+
+// TableStats holds the statistics of a table.
+type TableStats struct {
+	Rows int64
+	// Regions holds the row counts of the regions in the key range
+	Regions []RegionStats
+	// Families holds the statistics of every family, in full mode
+	Families map[string]*FamilyStats
+}
+
+// RegionStats holds the row count of a region.
+type RegionStats struct {
+	StartKey Text
+	EndKey   Text
+	Rows     int64
+}
+
+// FamilyStats holds the statistics of the latest cells of a family.
+type FamilyStats struct {
+	Cells      int64
+	ValueBytes int64
+	// Versions is the number of versions of the cells, counted up to
+	// StatsOptions.MaxVersions per cell
+	Versions int64
+	// ValueSizes estimates the distribution of value sizes
+	ValueSizes SizeStats
+
+	sample sizeSample
+}
+
+// SizeStats summarizes a distribution of sizes in bytes.
+type SizeStats struct {
+	Avg float64
+	P50 int
+	P90 int
+	P99 int
+	Max int
+}
+
+// sizeSample is a reservoir sample of sizes
+type sizeSample struct {
+	n     int64
+	sizes []int
+	max   int
+	rand  *rand.Rand
+}
+
+func (s *sizeSample) add(size int) {
+	s.n++
+	if size > s.max {
+		s.max = size
+	}
+	if len(s.sizes) < sizeSampleSize {
+		s.sizes = append(s.sizes, size)
+		return
+	}
+	if s.rand == nil {
+		s.rand = rand.New(rand.NewSource(1))
+	}
+	if i := s.rand.Int63n(s.n); i < sizeSampleSize {
+		s.sizes[i] = size
+	}
+}
+
+// percentile returns the p-th percentile of the sample, p in [0, 1].
+func (s *sizeSample) percentile(p float64) int {
+	if len(s.sizes) == 0 {
+		return 0
+	}
+	i := int(p * float64(len(s.sizes)-1))
+	return s.sizes[i]
+}
+
+// summarize computes the size statistics of a family.
+func (f *FamilyStats) summarize() {
+	sort.Ints(f.sample.sizes)
+	if f.Cells > 0 {
+		f.ValueSizes.Avg = float64(f.ValueBytes) / float64(f.Cells)
+	}
+	f.ValueSizes.P50 = f.sample.percentile(0.5)
+	f.ValueSizes.P90 = f.sample.percentile(0.9)
+	f.ValueSizes.P99 = f.sample.percentile(0.99)
+	f.ValueSizes.Max = f.sample.max
+	f.sample = sizeSample{}
+}
+
+// familyOf returns the family of column.
+func familyOf(column []byte) string {
+	for i, b := range column {
+		if b == ':' {
+			return string(column[:i])
+		}
+	}
+	return string(column)
+}
+
+// TableStatistics counts the rows of table, region by region, and in full
+// mode collects per family statistics of the latest cells.
+func TableStatistics(h Hbase, table Text, opts StatsOptions) (*TableStats, error) {
+	ranges, err := regionRanges(h, table, opts.StartRow, opts.StopRow)
+	if err != nil {
+		return nil, err
+	}
+	stats := &TableStats{}
+	if opts.Full {
+		stats.Families = map[string]*FamilyStats{}
+	}
+	for _, r := range ranges {
+		region := RegionStats{StartKey: r.start, EndKey: r.stop}
+		export := &ExportOptions{
+			StartRow:  r.start,
+			StopRow:   r.stop,
+			Columns:   opts.Columns,
+			BatchSize: opts.BatchSize,
+		}
+		if !opts.Full {
+			export.FilterString = Text(countFilter)
+		}
+		err := scanRows(h, table, export.scan(), opts.BatchSize, func(result *TRowResult_) error {
+			region.Rows++
+			if !opts.Full {
+				return nil
+			}
+			for _, col := range sortedColumns(result) {
+				f := stats.Families[familyOf(col.ColumnName)]
+				if f == nil {
+					f = &FamilyStats{}
+					stats.Families[familyOf(col.ColumnName)] = f
+				}
+				f.Cells++
+				f.ValueBytes += int64(len(col.Cell.Value))
+				f.sample.add(len(col.Cell.Value))
+				if opts.MaxVersions > 0 {
+					versions, err := h.GetVer(table, result.Row, col.ColumnName, opts.MaxVersions, nil)
+					if err != nil {
+						return err
+					}
+					f.Versions += int64(len(versions))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		stats.Rows += region.Rows
+		stats.Regions = append(stats.Regions, region)
+	}
+	for _, f := range stats.Families {
+		f.summarize()
+	}
+	return stats, nil
+}
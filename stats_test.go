@@ -0,0 +1,36 @@
+package hbase
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableStatistics(t *testing.T) {
+	m := newMemHbase()
+	m.createTable("table", "m")
+	for i, row := range []string{"a", "b", "n"} {
+		m.put("table", row, "cf:q", strings.Repeat("x", 10*(i+1)), 10)
+		m.put("table", row, "cf:q", strings.Repeat("x", 10*(i+1)), 20)
+	}
+	m.put("table", "n", "other:q", "", 10)
+
+	stats, err := TableStatistics(m, Text("table"), StatsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Rows != 3 || len(stats.Regions) != 2 || stats.Regions[0].Rows != 2 || stats.Families != nil {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	stats, err = TableStatistics(m, Text("table"), StatsOptions{Full: true, MaxVersions: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cf := stats.Families["cf"]
+	if stats.Rows != 3 || len(stats.Families) != 2 || cf.Cells != 3 || cf.Versions != 6 || cf.ValueBytes != 60 {
+		t.Fatalf("unexpected family stats %+v", cf)
+	}
+	if cf.ValueSizes != (SizeStats{Avg: 20, P50: 20, P90: 20, P99: 20, Max: 30}) {
+		t.Fatalf("unexpected value sizes %+v", cf.ValueSizes)
+	}
+}
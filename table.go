@@ -0,0 +1,270 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// Table is a typed view of one column of an HBase table. Row keys are
+// encoded from K with a key codec and cells from V with a value codec, so
+// that
+//
+//	users := hbase.NewTable[int64, User](conn, "users", "d:json", hbase.Int64Codec, hbase.JSONCodec)
+//	u, ok, err := users.Get(ctx, 42)
+//
+// reads the latest d:json cell of the row keyed by 42 as 8 big-endian
+// bytes and decodes it from JSON. Keys
+// used as scan bounds must encode to byte strings which sort like the keys
+// themselves, as Int64Codec does for non-negative integers.
+//
+// A Table adds no state of its own to h and is safe for concurrent use when
+// h is, e.g. a WrapConn or a Mux.
+type Table[K comparable, V any] struct {
+	// BatchSize is the number of rows fetched per ScannerGetList, 100 if
+	// zero
+	BatchSize int32
+
+	h      Hbase
+	name   Text
+	column Text
+	keys   Codec
+	values Codec
+}
+
+// NewTable returns a Table of column, a family:qualifier, of table name.
+func NewTable[K comparable, V any](h Hbase, name, column string, keys, values Codec) *Table[K, V] {
+	return &Table[K, V]{
+		h:      h,
+		name:   Text(name),
+		column: Text(column),
+		keys:   keys,
+		values: values,
+	}
+}
+
+// KeyRange bounds a Scan. A nil Start or Stop leaves that end of the range
+// open; Stop is exclusive.
+type KeyRange[K any] struct {
+	Start, Stop *K
+}
+
+func (t *Table[K, V]) encodeKey(key K) (Text, error) {
+	row, err := t.keys.Encode(key)
+	if err != nil {
+		return nil, fmt.Errorf("encode key %v: %w", key, err)
+	}
+	return Text(row), nil
+}
+
+func (t *Table[K, V]) decodeValue(row Text, value []byte) (V, error) {
+	var v V
+	if err := t.values.Decode(value, &v); err != nil {
+		return v, fmt.Errorf("decode %s of row %s: %w", t.column, ToStringBinary(row), err)
+	}
+	return v, nil
+}
+
+// cell returns the cell of the column of r, nil if r has none.
+func (t *Table[K, V]) cell(r *TRowResult_) *TCell {
+	c, _ := NewRowView(r).Latest(SplitColumn(t.column))
+	return c
+}
+
+func (t *Table[K, V]) put(value V) (*Mutation, error) {
+	b, err := t.values.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("encode value: %w", err)
+	}
+	m := NewMutation()
+	m.Column = t.column
+	m.Value = b
+	return m, nil
+}
+
+// Get returns the value of key, false if the cell does not exist.
+func (t *Table[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	var zero V
+	h, err := contextHbase(ctx, t.h)
+	if err != nil {
+		return zero, false, err
+	}
+	row, err := t.encodeKey(key)
+	if err != nil {
+		return zero, false, err
+	}
+	cells, err := h.Get(t.name, row, t.column, nil)
+	if err != nil || len(cells) == 0 {
+		return zero, false, err
+	}
+	v, err := t.decodeValue(row, cells[0].Value)
+	return v, err == nil, err
+}
+
+// Put writes value under key.
+func (t *Table[K, V]) Put(ctx context.Context, key K, value V) error {
+	h, err := contextHbase(ctx, t.h)
+	if err != nil {
+		return err
+	}
+	row, err := t.encodeKey(key)
+	if err != nil {
+		return err
+	}
+	m, err := t.put(value)
+	if err != nil {
+		return err
+	}
+	return h.MutateRow(t.name, row, []*Mutation{m}, nil)
+}
+
+// Delete deletes all versions of the cell of key.
+func (t *Table[K, V]) Delete(ctx context.Context, key K) error {
+	h, err := contextHbase(ctx, t.h)
+	if err != nil {
+		return err
+	}
+	row, err := t.encodeKey(key)
+	if err != nil {
+		return err
+	}
+	m := NewMutation()
+	m.IsDelete = true
+	m.Column = t.column
+	return h.MutateRow(t.name, row, []*Mutation{m}, nil)
+}
+
+// BatchGet returns the values of keys in one GetRowsWithColumns call. Keys
+// without a cell are left out of the result.
+func (t *Table[K, V]) BatchGet(ctx context.Context, keys []K) (map[K]V, error) {
+	h, err := contextHbase(ctx, t.h)
+	if err != nil {
+		return nil, err
+	}
+	byRow := make(map[string]K, len(keys))
+	rows := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		row, err := t.encodeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byRow[string(row)]; ok {
+			continue
+		}
+		byRow[string(row)] = key
+		rows = append(rows, row)
+	}
+	values := make(map[K]V, len(rows))
+	if len(rows) == 0 {
+		return values, nil
+	}
+	results, err := h.GetRowsWithColumns(t.name, rows, [][]byte{t.column}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		key, ok := byRow[string(r.Row)]
+		cell := t.cell(r)
+		if !ok || cell == nil {
+			continue
+		}
+		v, err := t.decodeValue(r.Row, cell.Value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// CompareAndSwap writes value under key if the current value encodes to
+// the same bytes as old, and reports whether it did.
+func (t *Table[K, V]) CompareAndSwap(ctx context.Context, key K, old, value V) (bool, error) {
+	expected, err := t.values.Encode(old)
+	if err != nil {
+		return false, fmt.Errorf("encode value: %w", err)
+	}
+	return t.checkAndPut(ctx, key, expected, value)
+}
+
+// PutIfAbsent writes value under key if the cell does not exist, or is
+// empty, and reports whether it did.
+func (t *Table[K, V]) PutIfAbsent(ctx context.Context, key K, value V) (bool, error) {
+	return t.checkAndPut(ctx, key, nil, value)
+}
+
+func (t *Table[K, V]) checkAndPut(ctx context.Context, key K, expected []byte, value V) (bool, error) {
+	h, err := contextHbase(ctx, t.h)
+	if err != nil {
+		return false, err
+	}
+	row, err := t.encodeKey(key)
+	if err != nil {
+		return false, err
+	}
+	m, err := t.put(value)
+	if err != nil {
+		return false, err
+	}
+	return h.CheckAndPut(t.name, row, t.column, expected, m, nil)
+}
+
+// Scan returns an iterator over the keys and values of r in key order, and
+// a function returning the error which ended the iteration early, if any.
+// The scanner is closed when the iteration ends, including when the loop
+// breaks:
+//
+//	entries, errf := users.Scan(ctx, hbase.KeyRange[int64]{})
+//	for id, u := range entries {
+//		...
+//	}
+//	if err := errf(); err != nil {
+//		...
+//	}
+func (t *Table[K, V]) Scan(ctx context.Context, r KeyRange[K]) (iter.Seq2[K, V], func() error) {
+	var scanErr error
+	seq := func(yield func(K, V) bool) {
+		scanErr = t.scan(ctx, r, yield)
+	}
+	return seq, func() error { return scanErr }
+}
+
+func (t *Table[K, V]) scan(ctx context.Context, r KeyRange[K], yield func(K, V) bool) error {
+	scan := NewTScan()
+	scan.Columns = [][]byte{t.column}
+	if t.BatchSize > 0 {
+		scan.Caching = &t.BatchSize
+	}
+	var err error
+	if r.Start != nil {
+		if scan.StartRow, err = t.encodeKey(*r.Start); err != nil {
+			return err
+		}
+	}
+	if r.Stop != nil {
+		if scan.StopRow, err = t.encodeKey(*r.Stop); err != nil {
+			return err
+		}
+	}
+	for row, err := range Scan(ctx, t.h, t.name, scan) {
+		if err != nil {
+			return err
+		}
+		cell := t.cell(row)
+		if cell == nil {
+			continue
+		}
+		var key K
+		if err := t.keys.Decode(row.Row, &key); err != nil {
+			return fmt.Errorf("decode key %s: %w", ToStringBinary(row.Row), err)
+		}
+		v, err := t.decodeValue(row.Row, cell.Value)
+		if err != nil {
+			return err
+		}
+		if !yield(key, v) {
+			return nil
+		}
+	}
+	return nil
+}
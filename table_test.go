@@ -0,0 +1,86 @@
+package hbase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type tableUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestTable(t *testing.T) {
+	ctx := context.Background()
+	m := newMemHbase()
+	m.createTable("users")
+	users := NewTable[int64, tableUser](m, "users", "d:json", Int64Codec, JSONCodec)
+	users.BatchSize = 2
+
+	if _, ok, err := users.Get(ctx, 1); err != nil || ok {
+		t.Fatalf("unexpected get of missing key: %v, %v", ok, err)
+	}
+	for i, name := range []string{"ann", "bob", "cid", "dee", "eve"} {
+		if err := users.Put(ctx, int64(i+1), tableUser{Name: name, Age: 20 + i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a row without the column of the table is skipped by scans
+	m.put("users", "\x00\x00\x00\x00\x00\x00\x00\x06", "d:other", "x", 0)
+
+	if u, ok, err := users.Get(ctx, 2); err != nil || !ok || u != (tableUser{"bob", 21}) {
+		t.Fatalf("unexpected get %+v, %v, %v", u, ok, err)
+	}
+	got, err := users.BatchGet(ctx, []int64{5, 1, 9, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int64]tableUser{1: {"ann", 20}, 5: {"eve", 24}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected batch get %v", got)
+	}
+
+	start, stop := int64(2), int64(7)
+	entries, errf := users.Scan(ctx, KeyRange[int64]{Start: &start, Stop: &stop})
+	var keys []int64
+	for k, u := range entries {
+		keys = append(keys, k)
+		if k == 4 {
+			if u.Name != "dee" {
+				t.Fatalf("unexpected value %+v", u)
+			}
+			break
+		}
+	}
+	if err := errf(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []int64{2, 3, 4}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if len(m.scanners) != 0 {
+		t.Fatalf("scanner left open after break")
+	}
+
+	if ok, err := users.CompareAndSwap(ctx, 3, tableUser{"cid", 99}, tableUser{"cid", 23}); err != nil || ok {
+		t.Fatalf("unexpected swap of stale value: %v, %v", ok, err)
+	}
+	if ok, err := users.CompareAndSwap(ctx, 3, tableUser{"cid", 22}, tableUser{"cid", 23}); err != nil || !ok {
+		t.Fatalf("unexpected swap failure: %v, %v", ok, err)
+	}
+	if ok, err := users.PutIfAbsent(ctx, 3, tableUser{"new", 1}); err != nil || ok {
+		t.Fatalf("unexpected put over existing key: %v, %v", ok, err)
+	}
+	if err := users.Delete(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := users.PutIfAbsent(ctx, 3, tableUser{"new", 1}); err != nil || !ok {
+		t.Fatalf("unexpected put failure: %v, %v", ok, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := users.Put(cancelled, 7, tableUser{}); err != context.Canceled {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// valueTransform is the base of interceptors which rewrite the values of
+// some columns, e.g. to compress or encrypt them: encode runs on mutation
+// values before they are sent and decode on the cells of results. Both
+// receive the table, row and column of the value.
+//
+// Appends to and CheckAndPut comparisons against managed columns are
+// rejected, since the gateway would operate on the encoded bytes.
+type valueTransform struct {
+	// name prefixes the errors of the transform
+	name string
+	// manages reports whether the transform applies to a column
+	manages func(table, column Text) bool
+	encode  func(ctx context.Context, table, row, column Text, value []byte) ([]byte, error)
+	decode  func(ctx context.Context, table, row, column Text, value []byte) ([]byte, error)
+
+	// mu guards scanners, which maps open scanners to their table
+	mu       sync.Mutex
+	scanners map[ScannerID]Text
+}
+
+func newValueTransform(name string) *valueTransform {
+	return &valueTransform{name: name, scanners: map[ScannerID]Text{}}
+}
+
+// interceptor returns the Interceptor applying the transform.
+func (t *valueTransform) interceptor() Interceptor {
+	return func(ctx context.Context, method string, args, result interface{}, invoker Invoker) error {
+		args, err := t.encodeArgs(ctx, args)
+		if err != nil {
+			return err
+		}
+		if err := invoker(ctx, method, args, result); err != nil {
+			return err
+		}
+		return t.decodeResult(ctx, args, result)
+	}
+}
+
+// mutations returns encoded copies of the mutations of row.
+func (t *valueTransform) mutations(ctx context.Context, table, row Text, mutations []*Mutation) ([]*Mutation, error) {
+	encoded := make([]*Mutation, len(mutations))
+	for i, m := range mutations {
+		encoded[i] = m
+		if m == nil || m.IsDelete || !t.manages(table, m.Column) {
+			continue
+		}
+		value, err := t.encode(ctx, table, row, m.Column, m.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", t.name, []byte(m.Column), err)
+		}
+		c := *m
+		c.Value = value
+		encoded[i] = &c
+	}
+	return encoded, nil
+}
+
+// batches returns encoded copies of row batches.
+func (t *valueTransform) batches(ctx context.Context, table Text, batches []*BatchMutation) ([]*BatchMutation, error) {
+	encoded := make([]*BatchMutation, len(batches))
+	for i, b := range batches {
+		mutations, err := t.mutations(ctx, table, b.Row, b.Mutations)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = &BatchMutation{Row: b.Row, Mutations: mutations}
+	}
+	return encoded, nil
+}
+
+// encodeArgs returns a copy of args with encoded mutation values. args
+// themselves are not modified as they belong to the caller.
+func (t *valueTransform) encodeArgs(ctx context.Context, args interface{}) (interface{}, error) {
+	var err error
+	switch a := args.(type) {
+	case *MutateRowArgs:
+		c := *a
+		c.Mutations, err = t.mutations(ctx, a.TableName, a.Row, a.Mutations)
+		return &c, err
+	case *MutateRowTsArgs:
+		c := *a
+		c.Mutations, err = t.mutations(ctx, a.TableName, a.Row, a.Mutations)
+		return &c, err
+	case *MutateRowsArgs:
+		c := *a
+		c.RowBatches, err = t.batches(ctx, a.TableName, a.RowBatches)
+		return &c, err
+	case *MutateRowsTsArgs:
+		c := *a
+		c.RowBatches, err = t.batches(ctx, a.TableName, a.RowBatches)
+		return &c, err
+	case *CheckAndPutArgs:
+		if a.Value != nil && t.manages(a.TableName, a.Column) {
+			return nil, &IllegalArgument{Message: fmt.Sprintf("%s: cannot compare values of column %q", t.name, []byte(a.Column))}
+		}
+		if a.Mput == nil {
+			return args, nil
+		}
+		mutations, err := t.mutations(ctx, a.TableName, a.Row, []*Mutation{a.Mput})
+		if err != nil {
+			return nil, err
+		}
+		c := *a
+		c.Mput = mutations[0]
+		return &c, nil
+	case *AppendArgs:
+		if a.Append == nil {
+			return args, nil
+		}
+		for _, column := range a.Append.Columns {
+			if t.manages(a.Append.Table, column) {
+				return nil, &IllegalArgument{Message: fmt.Sprintf("%s: cannot append to column %q", t.name, column)}
+			}
+		}
+	}
+	return args, nil
+}
+
+// decodeResult replaces the cells of a result with decoded copies and
+// tracks the tables of open scanners.
+func (t *valueTransform) decodeResult(ctx context.Context, args, result interface{}) error {
+	switch a := args.(type) {
+	case *ScannerCloseArgs:
+		t.mu.Lock()
+		delete(t.scanners, a.Id)
+		t.mu.Unlock()
+		return nil
+	}
+	success := resultSuccess(result)
+	if !success.IsValid() {
+		return nil
+	}
+	switch v := success.Interface().(type) {
+	case *ScannerID:
+		// ScannerOpen* calls return the id of the scanner
+		if v != nil {
+			t.mu.Lock()
+			t.scanners[*v] = TableOf(args)
+			t.mu.Unlock()
+		}
+	case []*TCell:
+		table, row, column := TableOf(args), RowOf(args), argsField(args, "Column")
+		if !column.IsValid() || v == nil {
+			return nil
+		}
+		cells := make([]*TCell, len(v))
+		for i, cell := range v {
+			decoded, err := t.decodeCell(ctx, table, row, Text(column.Bytes()), cell)
+			if err != nil {
+				return err
+			}
+			cells[i] = decoded
+		}
+		return setSuccess(result, reflect.ValueOf(cells))
+	case []*TRowResult_:
+		table := TableOf(args)
+		if id := argsField(args, "Id"); id.IsValid() {
+			t.mu.Lock()
+			table = t.scanners[ScannerID(id.Int())]
+			t.mu.Unlock()
+		}
+		if v == nil {
+			return nil
+		}
+		rows := make([]*TRowResult_, len(v))
+		for i, r := range v {
+			decoded, err := t.decodeRow(ctx, table, r)
+			if err != nil {
+				return err
+			}
+			rows[i] = decoded
+		}
+		return setSuccess(result, reflect.ValueOf(rows))
+	}
+	return nil
+}
+
+// decodeRow returns a copy of r with the cells of managed columns decoded.
+// The columns of r are copied as well as results may share them with the
+// caller of the wrapped Hbase.
+func (t *valueTransform) decodeRow(ctx context.Context, table Text, r *TRowResult_) (*TRowResult_, error) {
+	if r == nil {
+		return nil, nil
+	}
+	decoded := *r
+	if r.Columns != nil {
+		decoded.Columns = make(map[string]*TCell, len(r.Columns))
+		for name, cell := range r.Columns {
+			c, err := t.decodeCell(ctx, table, r.Row, Text(name), cell)
+			if err != nil {
+				return nil, err
+			}
+			decoded.Columns[name] = c
+		}
+	}
+	if r.SortedColumns != nil {
+		decoded.SortedColumns = make([]*TColumn, len(r.SortedColumns))
+		for i, col := range r.SortedColumns {
+			decoded.SortedColumns[i] = col
+			if col == nil {
+				continue
+			}
+			c, err := t.decodeCell(ctx, table, r.Row, col.ColumnName, col.Cell)
+			if err != nil {
+				return nil, err
+			}
+			if c != col.Cell {
+				decoded.SortedColumns[i] = &TColumn{ColumnName: col.ColumnName, Cell: c}
+			}
+		}
+	}
+	return &decoded, nil
+}
+
+// decodeCell returns a copy of a cell of a managed column with its value
+// decoded. Cells are copied as results may share them with the caller of
+// the wrapped Hbase, e.g. an in-memory implementation.
+func (t *valueTransform) decodeCell(ctx context.Context, table, row, column Text, cell *TCell) (*TCell, error) {
+	if cell == nil || !t.manages(table, column) {
+		return cell, nil
+	}
+	value, err := t.decode(ctx, table, row, column, cell.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: %w", t.name, []byte(column), err)
+	}
+	return &TCell{Value: value, Timestamp: cell.Timestamp}, nil
+}
@@ -148,7 +148,7 @@ func (p *TCell) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("TCell(%+v)", *p)
+	return fmt.Sprintf("TCell({Value:%s Timestamp:%d})", ToStringBinary(p.Value), p.Timestamp)
 }
 
 type ColumnDescriptor struct {
@@ -1008,7 +1008,8 @@ func (p *Mutation) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("Mutation(%+v)", *p)
+	return fmt.Sprintf("Mutation({IsDelete:%v Column:%s Value:%s WriteToWAL:%v})",
+		p.IsDelete, ToStringBinary(p.Column), ToStringBinary(p.Value), p.WriteToWAL)
 }
 
 type BatchMutation struct {
@@ -1476,7 +1477,7 @@ func (p *TColumn) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("TColumn(%+v)", *p)
+	return fmt.Sprintf("TColumn({ColumnName:%s Cell:%s})", ToStringBinary(p.ColumnName), p.Cell)
 }
 
 type TRowResult_ struct {
@@ -1697,7 +1698,8 @@ func (p *TRowResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("TRowResult_(%+v)", *p)
+	return fmt.Sprintf("TRowResult_({Row:%s Columns:%s SortedColumns:%v})",
+		ToStringBinary(p.Row), stringBinaryCells(p.Columns), p.SortedColumns)
 }
 
 type TScan struct {
@@ -2169,7 +2171,10 @@ func (p *TScan) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("TScan(%+v)", *p)
+	return fmt.Sprintf("TScan({StartRow:%s StopRow:%s Timestamp:%s Columns:%s Caching:%s FilterString:%s BatchSize:%s SortColumns:%s Reversed:%s})",
+		ToStringBinary(p.StartRow), ToStringBinary(p.StopRow), stringOptional(p.Timestamp),
+		stringBinaryList(p.Columns), stringOptional(p.Caching), ToStringBinary(p.FilterString),
+		stringOptional(p.BatchSize), stringOptional(p.SortColumns), stringOptional(p.Reversed))
 }
 
 type TAppend struct {
@@ -0,0 +1,379 @@
+package hbase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// MismatchKind classifies the differences found by Verify
+type MismatchKind int
+
+const (
+	// MissingRow is a source row missing from the target
+	MissingRow MismatchKind = iota
+	// ExtraRow is a target row missing from the source
+	ExtraRow
+	// MissingCell is a source cell missing from a target row
+	MissingCell
+	// ExtraCell is a target cell missing from a source row
+	ExtraCell
+	// ValueMismatch is a cell whose values differ
+	ValueMismatch
+	// TimestampMismatch is a cell whose values match but timestamps differ
+	TimestampMismatch
+)
+
+func (k MismatchKind) String() string {
+	switch k {
+	case MissingRow:
+		return "missing row"
+	case ExtraRow:
+		return "extra row"
+	case MissingCell:
+		return "missing cell"
+	case ExtraCell:
+		return "extra cell"
+	case ValueMismatch:
+		return "value mismatch"
+	case TimestampMismatch:
+		return "timestamp mismatch"
+	}
+	return fmt.Sprintf("MismatchKind(%d)", int(k))
+}
+
+// Mismatch is a difference between the source and the target table.
+type Mismatch struct {
+	Kind MismatchKind
+	Row  Text
+	// Column is empty for missing and extra rows
+	Column Text
+	// Source and Target are the differing cells, nil if missing
+	Source *TCell
+	Target *TCell
+}
+
+func (m Mismatch) String() string {
+	if len(m.Column) == 0 {
+		return fmt.Sprintf("%v %s", m.Kind, ToStringBinary(m.Row))
+	}
+	return fmt.Sprintf("%v %s %s: source %v, target %v", m.Kind, ToStringBinary(m.Row), ToStringBinary(m.Column), m.Source, m.Target)
+}
+
+// VerifyOptions configures Verify.
+type VerifyOptions struct {
+	// StartRow and StopRow restrict the verification to a key range
+	StartRow Text
+	StopRow  Text
+	// Columns restricts the verification to these families or
+	// family:qualifier columns
+	Columns [][]byte
+	// Workers is the number of regions verified concurrently, defaults to
+	// 1. Both connections must be safe for concurrent use.
+	Workers int
+	// BatchSize is the number of rows fetched per call
+	BatchSize int32
+	// SampleSize is the number of mismatches kept in the report, defaults
+	// to 10
+	SampleSize int
+	// Checksum only compares a checksum of every region range instead of
+	// comparing rows. Mismatching ranges can then be verified row by row.
+	Checksum bool
+}
+
+// RangeChecksum is the checksum of the rows of a key range.
+type RangeChecksum struct {
+	StartRow, StopRow Text
+	SourceRows        int
+	TargetRows        int
+	Source            uint64
+	Target            uint64
+}
+
+// VerifyReport reports the differences found by Verify.
+type VerifyReport struct {
+	// Rows is the number of distinct rows compared, and Matched the number
+	// of them equal in both tables
+	Rows    int
+	Matched int
+	// Counts counts the mismatches by kind
+	Counts map[MismatchKind]int
+	// Samples holds the first mismatches found
+	Samples []Mismatch
+	// Ranges holds the mismatching ranges of a checksum verification
+	Ranges []RangeChecksum
+}
+
+// OK reports whether no difference was found.
+func (r *VerifyReport) OK() bool {
+	return len(r.Counts) == 0 && len(r.Ranges) == 0
+}
+
+// rowCursor iterates over the rows of a scanner
+type rowCursor struct {
+	h     Hbase
+	id    ScannerID
+	batch int32
+	rows  []*TRowResult_
+	done  bool
+}
+
+func openCursor(h Hbase, table Text, scan *TScan, batch int32) (*rowCursor, error) {
+	id, err := h.ScannerOpenWithScan(table, scan, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &rowCursor{h: h, id: id, batch: batch}, nil
+}
+
+// peek returns the current row, nil at the end of the scan.
+func (c *rowCursor) peek() (*TRowResult_, error) {
+	if len(c.rows) == 0 && !c.done {
+		rows, err := c.h.ScannerGetList(c.id, c.batch)
+		if err != nil {
+			return nil, err
+		}
+		c.rows = rows
+		c.done = len(rows) == 0
+	}
+	if len(c.rows) == 0 {
+		return nil, nil
+	}
+	return c.rows[0], nil
+}
+
+// pop advances past the current row.
+func (c *rowCursor) pop() {
+	c.rows = c.rows[1:]
+}
+
+func (c *rowCursor) close() {
+	c.h.ScannerClose(c.id)
+}
+
+// compareRows returns the differences between the cells of two rows.
+func compareRows(source, target *TRowResult_) []Mismatch {
+	var diffs []Mismatch
+	a, b := sortedColumns(source), sortedColumns(target)
+	for len(a) > 0 || len(b) > 0 {
+		cmp := 0
+		switch {
+		case len(a) == 0:
+			cmp = 1
+		case len(b) == 0:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(a[0].ColumnName, b[0].ColumnName)
+		}
+		switch {
+		case cmp < 0:
+			diffs = append(diffs, Mismatch{Kind: MissingCell, Row: source.Row, Column: a[0].ColumnName, Source: a[0].Cell})
+			a = a[1:]
+		case cmp > 0:
+			diffs = append(diffs, Mismatch{Kind: ExtraCell, Row: source.Row, Column: b[0].ColumnName, Target: b[0].Cell})
+			b = b[1:]
+		default:
+			m := Mismatch{Row: source.Row, Column: a[0].ColumnName, Source: a[0].Cell, Target: b[0].Cell}
+			if !bytes.Equal(a[0].Cell.Value, b[0].Cell.Value) {
+				m.Kind = ValueMismatch
+				diffs = append(diffs, m)
+			} else if a[0].Cell.Timestamp != b[0].Cell.Timestamp {
+				m.Kind = TimestampMismatch
+				diffs = append(diffs, m)
+			}
+			a, b = a[1:], b[1:]
+		}
+	}
+	return diffs
+}
+
+// verifier collects the report of a Verify
+type verifier struct {
+	source, target           Hbase
+	sourceTable, targetTable Text
+	opts                     VerifyOptions
+
+	mu     sync.Mutex
+	report VerifyReport
+}
+
+// add records the comparison of a row.
+func (v *verifier) add(diffs []Mismatch) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.report.Rows++
+	if len(diffs) == 0 {
+		v.report.Matched++
+	}
+	for _, d := range diffs {
+		v.report.Counts[d.Kind]++
+		if len(v.report.Samples) < v.opts.SampleSize {
+			v.report.Samples = append(v.report.Samples, d)
+		}
+	}
+}
+
+// scan returns the scan of a key range.
+func (v *verifier) scan(r keyRange) *TScan {
+	scan := (&ExportOptions{
+		StartRow:  r.start,
+		StopRow:   r.stop,
+		Columns:   v.opts.Columns,
+		BatchSize: v.opts.BatchSize,
+	}).scan()
+	sorted := true
+	scan.SortColumns = &sorted
+	return scan
+}
+
+// compareRange compares the rows of a key range of both tables.
+func (v *verifier) compareRange(r keyRange) error {
+	scan := v.scan(r)
+	source, err := openCursor(v.source, v.sourceTable, scan, v.opts.BatchSize)
+	if err != nil {
+		return err
+	}
+	defer source.close()
+	target, err := openCursor(v.target, v.targetTable, scan, v.opts.BatchSize)
+	if err != nil {
+		return err
+	}
+	defer target.close()
+
+	for {
+		a, err := source.peek()
+		if err != nil {
+			return err
+		}
+		b, err := target.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case a == nil && b == nil:
+			return nil
+		case b == nil || (a != nil && bytes.Compare(a.Row, b.Row) < 0):
+			v.add([]Mismatch{{Kind: MissingRow, Row: a.Row}})
+			source.pop()
+		case a == nil || bytes.Compare(a.Row, b.Row) > 0:
+			v.add([]Mismatch{{Kind: ExtraRow, Row: b.Row}})
+			target.pop()
+		default:
+			v.add(compareRows(a, b))
+			source.pop()
+			target.pop()
+		}
+	}
+}
+
+// checksum hashes the rows of a key range of a table.
+func (v *verifier) checksum(h Hbase, table Text, r keyRange) (uint64, int, error) {
+	sum := fnv.New64a()
+	rows := 0
+	err := scanRows(h, table, v.scan(r), v.opts.BatchSize, func(result *TRowResult_) error {
+		rows++
+		writeHashed(sum, result.Row)
+		for _, col := range sortedColumns(result) {
+			writeHashed(sum, col.ColumnName)
+			writeHashed(sum, col.Cell.Value)
+			binary.Write(sum, binary.BigEndian, col.Cell.Timestamp)
+		}
+		return nil
+	})
+	return sum.Sum64(), rows, err
+}
+
+// writeHashed hashes b with its length so that field boundaries are
+// unambiguous.
+func writeHashed(h hash.Hash64, b []byte) {
+	binary.Write(h, binary.BigEndian, uint32(len(b)))
+	h.Write(b)
+}
+
+// checksumRange compares the checksums of a key range of both tables.
+func (v *verifier) checksumRange(r keyRange) error {
+	c := RangeChecksum{StartRow: r.start, StopRow: r.stop}
+	var err error
+	if c.Source, c.SourceRows, err = v.checksum(v.source, v.sourceTable, r); err != nil {
+		return err
+	}
+	if c.Target, c.TargetRows, err = v.checksum(v.target, v.targetTable, r); err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.report.Rows += c.SourceRows
+	if c.Source != c.Target || c.SourceRows != c.TargetRows {
+		v.report.Ranges = append(v.report.Ranges, c)
+	} else {
+		v.report.Matched += c.SourceRows
+	}
+	return nil
+}
+
+// Verify compares sourceTable and targetTable row by row, or range by range
+// in checksum mode, splitting the key range at the source regions.
+func Verify(source Hbase, sourceTable Text, target Hbase, targetTable Text, opts VerifyOptions) (*VerifyReport, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultScanBatch
+	}
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = 10
+	}
+	ranges, err := regionRanges(source, sourceTable, opts.StartRow, opts.StopRow)
+	if err != nil {
+		return nil, err
+	}
+	v := &verifier{
+		source:      source,
+		target:      target,
+		sourceTable: sourceTable,
+		targetTable: targetTable,
+		opts:        opts,
+		report:      VerifyReport{Counts: map[MismatchKind]int{}},
+	}
+
+	work := make(chan keyRange, len(ranges))
+	for _, r := range ranges {
+		work <- r
+	}
+	close(work)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range work {
+				var err error
+				if opts.Checksum {
+					err = v.checksumRange(r)
+				} else {
+					err = v.compareRange(r)
+				}
+				if err != nil {
+					errOnce.Do(func() { firstErr = fmt.Errorf("range [%s, %s): %v", ToStringBinary(r.start), ToStringBinary(r.stop), err) })
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	sort.Slice(v.report.Ranges, func(i, j int) bool {
+		return bytes.Compare(v.report.Ranges[i].StartRow, v.report.Ranges[j].StartRow) < 0
+	})
+	return &v.report, nil
+}
@@ -0,0 +1,64 @@
+package hbase
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	source := newMemHbase()
+	source.createTable("table", "m")
+	target := newMemHbase()
+	target.createTable("table")
+	for _, row := range []string{"a", "k", "n", "z"} {
+		source.put("table", row, "cf:q", row, 10)
+		target.put("table", row, "cf:q", row, 10)
+	}
+
+	report, err := Verify(source, Text("table"), target, Text("table"), VerifyOptions{Workers: 2})
+	if err != nil || !report.OK() || report.Rows != 4 || report.Matched != 4 {
+		t.Fatalf("unexpected report %+v: %v", report, err)
+	}
+
+	source.put("table", "b", "cf:q", "b", 10)
+	target.put("table", "y", "cf:q", "y", 10)
+	target.put("table", "k", "cf:q", "changed", 20)
+	target.put("table", "n", "cf:q", "n", 30)
+	source.put("table", "z", "cf:r", "z", 10)
+	target.put("table", "z", "cf:s", "z", 10)
+
+	report, err = Verify(source, Text("table"), target, Text("table"), VerifyOptions{Workers: 2, SampleSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[MismatchKind]int{
+		MissingRow:        1,
+		ExtraRow:          1,
+		MissingCell:       1,
+		ExtraCell:         1,
+		ValueMismatch:     1,
+		TimestampMismatch: 1,
+	}
+	if report.OK() || report.Rows != 6 || report.Matched != 1 || len(report.Samples) != 6 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for kind, n := range expected {
+		if report.Counts[kind] != n {
+			t.Fatalf("unexpected %v count: %d", kind, report.Counts[kind])
+		}
+	}
+
+	m := Mismatch{Kind: MissingCell, Row: Text("\x00\xffr"), Column: Text("cf:\x01")}
+	if s := m.String(); !strings.HasPrefix(s, `missing cell \x00\xFFr cf:\x01: `) {
+		t.Fatalf("unexpected mismatch %s", s)
+	}
+
+	report, err = Verify(source, Text("table"), target, Text("table"), VerifyOptions{Checksum: true, StopRow: Text("b")})
+	if err != nil || !report.OK() || report.Rows != 1 {
+		t.Fatalf("unexpected checksum report %+v: %v", report, err)
+	}
+	report, err = Verify(source, Text("table"), target, Text("table"), VerifyOptions{Checksum: true})
+	if err != nil || len(report.Ranges) != 2 || report.Ranges[0].SourceRows == report.Ranges[0].TargetRows && report.Ranges[0].Source == report.Ranges[0].Target {
+		t.Fatalf("unexpected checksum report %+v: %v", report, err)
+	}
+}
@@ -22,6 +22,8 @@ func NewConn(client io.Closer) *WrapConn {
 // which implements HbaseConn and Hbase interface and also guarantee thread-safe property
 type WrapConn struct {
 	client *clientCloser
+	// codecs holds the codecs of GetValue and PutValue
+	codecs columnCodecs
 }
 
 // invokeMethodViaReflection invokes the given method cmd on the obj with